@@ -0,0 +1,114 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Address is a GPIB device address as used by the NI-488.2 routines: the
+// primary address in the low byte and the secondary address, or NO_SAD, in
+// the high byte. NOADDR terminates address lists in C and is not a valid
+// device address.
+type Address int16
+
+// MakeAddr returns the address with primary address pad and secondary
+// address sad, 0 (NO_SAD) or 96 to 126. It does not validate them, see
+// Address.Valid.
+func MakeAddr(pad, sad int) Address {
+	return Address(pad&0xFF | sad<<8)
+}
+
+// Primary returns the primary address.
+func (a Address) Primary() int {
+	return int(a & 0xFF)
+}
+
+// Secondary returns the secondary address, 96 to 126, or NO_SAD if the
+// address has none.
+func (a Address) Secondary() int {
+	return int(a>>8) & 0xFF
+}
+
+// Valid reports whether the primary address is 0 to 30 and the secondary
+// address, if any, 96 to 126.
+func (a Address) Valid() bool {
+	pad, sad := a.Primary(), a.Secondary()
+	return pad <= 30 && (sad == NO_SAD || (sad >= 0x60 && sad <= 0x7E))
+}
+
+// String returns the address as "22", or "22.96" with a secondary address.
+func (a Address) String() string {
+	switch {
+	case a == NOADDR:
+		return "NOADDR"
+	case a.Secondary() == NO_SAD:
+		return strconv.Itoa(a.Primary())
+	}
+	return strconv.Itoa(a.Primary()) + "." + strconv.Itoa(a.Secondary())
+}
+
+// ParseAddress parses an address written as "22", "22.96" or as a VISA
+// resource string such as "GPIB0::22::INSTR" or "GPIB0::22::96::INSTR",
+// in which case the board number is dropped, see ParseResource.
+func ParseAddress(s string) (Address, error) {
+	if strings.Contains(s, "::") {
+		_, a, err := ParseResource(s)
+		return a, err
+	}
+	i := strings.IndexByte(s, '.')
+	if i < 0 {
+		return parseAddress(s, s, "")
+	}
+	if i == len(s)-1 {
+		return 0, fmt.Errorf("ni488: invalid GPIB address %q", s)
+	}
+	return parseAddress(s, s[:i], s[i+1:])
+}
+
+// ParseResource parses a VISA GPIB INSTR resource string, e.g.
+// "GPIB0::22::96::INSTR", and returns the board number and the address.
+// The secondary address may be given in the VISA range, 0 to 30, or as
+// 96 to 126. The board number and the INSTR suffix are optional.
+func ParseResource(s string) (boardID int, a Address, err error) {
+	f := strings.Split(s, "::")
+	if len(f) > 1 && strings.EqualFold(f[len(f)-1], "INSTR") {
+		f = f[:len(f)-1]
+	}
+	if len(f) < 2 || len(f) > 3 || len(f[0]) < 4 || !strings.EqualFold(f[0][:4], "GPIB") {
+		return 0, 0, fmt.Errorf("ni488: invalid GPIB resource %q", s)
+	}
+	if f[0] != f[0][:4] {
+		if boardID, err = strconv.Atoi(f[0][4:]); err != nil || boardID < 0 {
+			return 0, 0, fmt.Errorf("ni488: invalid GPIB resource %q", s)
+		}
+	}
+	sad := ""
+	if len(f) == 3 {
+		sad = f[2]
+		if n, err := strconv.Atoi(sad); err == nil && n >= 0 && n <= 30 {
+			sad = strconv.Itoa(n + 0x60)
+		}
+	}
+	a, err = parseAddress(s, f[1], sad)
+	return boardID, a, err
+}
+
+func parseAddress(s, pad, sad string) (Address, error) {
+	p, err := strconv.Atoi(pad)
+	if err != nil || p < 0 || p > 30 {
+		return 0, fmt.Errorf("ni488: invalid GPIB address %q", s)
+	}
+	if sad == "" {
+		return MakeAddr(p, NO_SAD), nil
+	}
+	q, err := strconv.Atoi(sad)
+	if err != nil || q < 0x60 || q > 0x7E {
+		return 0, fmt.Errorf("ni488: invalid GPIB address %q", s)
+	}
+	return MakeAddr(p, q), nil
+}
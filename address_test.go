@@ -0,0 +1,114 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import "testing"
+
+func TestAddress(t *testing.T) {
+	tests := []struct {
+		a        Address
+		pad, sad int
+		valid    bool
+		s        string
+	}{
+		{MakeAddr(22, NO_SAD), 22, NO_SAD, true, "22"},
+		{MakeAddr(0, 0x60), 0, 0x60, true, "0.96"},
+		{MakeAddr(30, 0x7E), 30, 0x7E, true, "30.126"},
+		{MakeAddr(31, NO_SAD), 31, NO_SAD, false, "31"},
+		{MakeAddr(5, 0x7F), 5, 0x7F, false, "5.127"},
+		{MakeAddr(5, 3), 5, 3, false, "5.3"},
+		{NOADDR, 0xFF, 0xFF, false, "NOADDR"},
+	}
+	for _, tt := range tests {
+		if tt.a.Primary() != tt.pad || tt.a.Secondary() != tt.sad || tt.a.Valid() != tt.valid || tt.a.String() != tt.s {
+			t.Errorf("%#x: pad %d sad %d valid %v %q; want %d %d %v %q", int(tt.a),
+				tt.a.Primary(), tt.a.Secondary(), tt.a.Valid(), tt.a.String(), tt.pad, tt.sad, tt.valid, tt.s)
+		}
+	}
+}
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Address
+		ok   bool
+	}{
+		{"22", MakeAddr(22, NO_SAD), true},
+		{"0", MakeAddr(0, NO_SAD), true},
+		{"22.96", MakeAddr(22, 96), true},
+		{"1.126", MakeAddr(1, 126), true},
+		{"GPIB1::22::INSTR", MakeAddr(22, NO_SAD), true},
+		{"GPIB0::22::3::INSTR", MakeAddr(22, 99), true},
+		{"", 0, false},
+		{"31", 0, false},
+		{"-1", 0, false},
+		{"22.", 0, false},
+		{"22.95", 0, false},
+		{"22.127", 0, false},
+		{"22.96.1", 0, false},
+		{"x", 0, false},
+		{"GPIB0::31::INSTR", 0, false},
+	}
+	for _, tt := range tests {
+		a, err := ParseAddress(tt.s)
+		if (err == nil) != tt.ok || tt.ok && a != tt.want {
+			t.Errorf("ParseAddress(%q) = %v, %v; want %v", tt.s, a, err, tt.want)
+		}
+	}
+}
+
+func TestParseResource(t *testing.T) {
+	tests := []struct {
+		s     string
+		board int
+		want  Address
+		ok    bool
+	}{
+		{"GPIB0::22::INSTR", 0, MakeAddr(22, NO_SAD), true},
+		{"gpib2::5::instr", 2, MakeAddr(5, NO_SAD), true},
+		{"GPIB::5", 0, MakeAddr(5, NO_SAD), true},
+		{"GPIB1::22::96::INSTR", 1, MakeAddr(22, 96), true},
+		{"GPIB1::22::0::INSTR", 1, MakeAddr(22, 96), true}, // VISA secondary range
+		{"GPIB1::22::30", 1, MakeAddr(22, 126), true},
+		{"GPIB1::22::31::INSTR", 0, 0, false},
+		{"GPIB1::22::127::INSTR", 0, 0, false},
+		{"GPIB0::INSTR", 0, 0, false},
+		{"GPIB0::1::2::3::INSTR", 0, 0, false},
+		{"GPIB-1::5::INSTR", 0, 0, false},
+		{"GPIBx::5::INSTR", 0, 0, false},
+		{"ASRL1::INSTR", 0, 0, false},
+		{"TCPIP0::10.0.0.1::INSTR", 0, 0, false},
+		{"22", 0, 0, false},
+	}
+	for _, tt := range tests {
+		board, a, err := ParseResource(tt.s)
+		if (err == nil) != tt.ok || tt.ok && (board != tt.board || a != tt.want) {
+			t.Errorf("ParseResource(%q) = %d, %v, %v; want %d, %v", tt.s, board, a, err, tt.board, tt.want)
+		}
+	}
+}
+
+func TestAddressCalls(t *testing.T) {
+	s := useSim(t)
+	s.Attach(0, 9, 0x61, NewSimInstrument(nil))
+	addr := MakeAddr(9, 0x61)
+	for _, tt := range []struct {
+		addr   Address
+		listen bool
+	}{{addr, true}, {9, false}, {MakeAddr(9, 0x62), false}} {
+		if listen, _, err := IblnAddr(0, tt.addr); err != nil || listen != tt.listen {
+			t.Errorf("IblnAddr(%v) = %v, %v; want %v", tt.addr, listen, err, tt.listen)
+		}
+	}
+	ud, _, err := IbdevAddr(0, addr, T1s, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pad, _, _ := Ibask(ud, IbaPAD)
+	sad, _, _ := Ibask(ud, IbaSAD)
+	if pad != 9 || sad != 0x61 {
+		t.Errorf("IbdevAddr opened %d.%d, want %v", pad, sad, addr)
+	}
+}
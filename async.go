@@ -0,0 +1,129 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"context"
+	"sync"
+)
+
+// AsyncIO is an asynchronous transfer started by ReadAsync, WriteAsync or
+// CommandAsync. Only one can be in progress on a descriptor.
+type AsyncIO struct {
+	op  string
+	ud  int
+	n   int // the buffer length
+	ctx context.Context
+
+	mu       sync.Mutex
+	done     bool          // Wait saw the I/O complete
+	stopped  bool          // ctx was done first and Ibstop called
+	finished chan struct{} // closed when done is set
+
+	once sync.Once
+	r    Result
+	err  error
+}
+
+// ReadAsync starts reading up to len(buf) bytes from ud with ibrda. buf
+// must not be used until Wait returns.
+//
+// If ctx is done before the read completes, it is stopped with Ibstop.
+func ReadAsync(ctx context.Context, ud int, buf []byte) (*AsyncIO, error) {
+	return startAsync(ctx, "ibrda", ud, len(buf), driver().Ibrda(ud, buf))
+}
+
+// WriteAsync starts writing buf to ud with ibwrta. buf can be reused as
+// soon as WriteAsync returns.
+//
+// If ctx is done before the write completes, it is stopped with Ibstop.
+func WriteAsync(ctx context.Context, ud int, buf []byte) (*AsyncIO, error) {
+	return startAsync(ctx, "ibwrta", ud, len(buf), driver().Ibwrta(ud, buf))
+}
+
+// CommandAsync starts sending cmds as command bytes on the board ud with
+// ibcmda. cmds can be reused as soon as CommandAsync returns.
+//
+// If ctx is done before the commands are sent, the transfer is stopped
+// with Ibstop.
+func CommandAsync(ctx context.Context, ud int, cmds []byte) (*AsyncIO, error) {
+	return startAsync(ctx, "ibcmda", ud, len(cmds), driver().Ibcmda(ud, cmds))
+}
+
+func startAsync(ctx context.Context, op string, ud, n int, r Result) (*AsyncIO, error) {
+	if _, err := check(op, ud, r); err != nil {
+		return nil, err
+	}
+	a := &AsyncIO{op: op, ud: ud, n: n, ctx: ctx, finished: make(chan struct{})}
+	if ctx.Done() != nil {
+		go a.watch()
+	}
+	return a, nil
+}
+
+// watch stops the I/O when ctx is done, unless Wait has seen it complete.
+func (a *AsyncIO) watch() {
+	select {
+	case <-a.ctx.Done():
+	case <-a.finished:
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.done {
+		a.stopped = true
+		driver().Ibstop(a.ud)
+	}
+}
+
+// Ud returns the descriptor the transfer is made on.
+func (a *AsyncIO) Ud() int {
+	return a.ud
+}
+
+// Wait waits for the transfer to complete with Ibwait and returns the
+// number of bytes transferred. If the timeout of the descriptor expires
+// first, the transfer is stopped with Ibstop and the error matches both
+// ErrTimeout and ErrAborted. If ctx is done first, the error matches
+// ErrAborted and ctx.Err().
+//
+// Wait can be called more than once and from several goroutines, it
+// returns the same result each time.
+func (a *AsyncIO) Wait() (n int, err error) {
+	a.once.Do(a.wait)
+	return a.r.count(a.n), a.err
+}
+
+// Result returns the status the transfer completed with. It is valid once
+// Wait has returned.
+func (a *AsyncIO) Result() Result {
+	return a.r
+}
+
+func (a *AsyncIO) wait() {
+	r := driver().Ibwait(a.ud, TIMO|CMPL)
+	if r.Ibsta&(CMPL|ERR) == 0 {
+		// Timed out or stopped: the I/O is in progress until Ibstop.
+		timo := r.Ibsta & TIMO
+		r = driver().Ibstop(a.ud)
+		r.Ibsta |= timo
+	}
+	a.mu.Lock()
+	a.done = true
+	stopped := a.stopped
+	a.mu.Unlock()
+	close(a.finished)
+	a.r = r
+	switch {
+	// The I/O may have completed before Ibstop, which then had nothing
+	// to abort.
+	case stopped && r.Ibsta&ERR != 0 && r.Iberr == EABO:
+		a.err = aborted(a.op, a.ud, r, a.ctx.Err())
+	case r.Ibsta&TIMO != 0:
+		a.err = aborted(a.op, a.ud, r, nil)
+	default:
+		_, a.err = check(a.op, a.ud, r)
+	}
+}
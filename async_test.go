@@ -0,0 +1,171 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAsyncIO(t *testing.T) {
+	s := useSim(t)
+	var got string
+	s.Attach(0, 5, NO_SAD, NewSimInstrument(func(msg string) []byte {
+		got = msg
+		return []byte("ACME,DMM1,42,1.0\n")
+	}))
+	ud, _, err := Ibdev(0, 5, NO_SAD, T1s, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := WriteAsync(context.Background(), ud, []byte("*IDN?\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := w.Wait(); n != 6 || err != nil || got != "*IDN?" {
+		t.Fatalf("write Wait = %d, %v; instrument got %q", n, err, got)
+	}
+	buf := make([]byte, 64)
+	rd, err := ReadAsync(context.Background(), ud, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := rd.Wait()
+	if err != nil || string(buf[:n]) != "ACME,DMM1,42,1.0\n" {
+		t.Errorf("read Wait = %q, %v", buf[:n], err)
+	}
+	if n2, err2 := rd.Wait(); n2 != n || err2 != err {
+		t.Errorf("second Wait = %d, %v; want %d, %v", n2, err2, n, err)
+	}
+}
+
+// stopDriver is a Sim reporting its calls to Ibstop.
+type stopDriver struct {
+	*Sim
+	stopped chan struct{}
+}
+
+func (s *stopDriver) Ibstop(ud int) Result {
+	r := s.Sim.Ibstop(ud)
+	close(s.stopped)
+	return r
+}
+
+func TestAsyncIOCompletedBeforeCancel(t *testing.T) {
+	s := &stopDriver{Sim: NewSim(), stopped: make(chan struct{})}
+	prev := SetDriver(s)
+	defer SetDriver(prev)
+	inst, ud := simDev(t, s.Sim, T1s, 0)
+	inst.Queue([]byte("done"))
+	ctx, cancel := context.WithCancel(context.Background())
+	buf := make([]byte, 8)
+	rd, err := ReadAsync(ctx, ud, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The read has completed, cancelling stops nothing, and Wait
+	// reports the data read.
+	cancel()
+	select {
+	case <-s.stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Ibstop not called after cancel")
+	}
+	if n, err := rd.Wait(); err != nil || string(buf[:n]) != "done" {
+		t.Errorf("Wait = %q, %v; want the data read", buf[:n], err)
+	}
+}
+
+// pendingDriver is a Sim whose asynchronous reads stay in progress until
+// Ibstop, or until Ibwait times out, as those of a silent device do with
+// the NI driver.
+type pendingDriver struct {
+	*Sim
+	tmo  time.Duration
+	stop chan struct{}
+}
+
+func (p *pendingDriver) Ibrda(ud int, buf []byte) Result {
+	p.stop = make(chan struct{})
+	return Result{} // in progress
+}
+
+func (p *pendingDriver) Ibwait(ud int, mask Status) Result {
+	select {
+	case <-p.stop:
+		return Result{Ibsta: ERR | CMPL, Iberr: EABO}
+	case <-time.After(p.tmo):
+		return Result{Ibsta: TIMO}
+	}
+}
+
+func (p *pendingDriver) Ibstop(ud int) Result {
+	select {
+	case <-p.stop:
+		return Result{Ibsta: CMPL}
+	default:
+		close(p.stop)
+		return Result{Ibsta: ERR | CMPL, Iberr: EABO}
+	}
+}
+
+func TestAsyncIOAbort(t *testing.T) {
+	tests := []struct {
+		name   string
+		tmo    time.Duration
+		ctx    func() (context.Context, context.CancelFunc)
+		want   []error
+		others []error // errors the result must not match
+	}{
+		{"timeout", 30 * time.Millisecond,
+			func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+			[]error{ErrAborted, ErrTimeout}, []error{context.Canceled}},
+		{"cancel", 10 * time.Second,
+			func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(30*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			[]error{ErrAborted, context.Canceled}, []error{ErrTimeout, context.DeadlineExceeded}},
+		{"deadline", 10 * time.Second,
+			func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 30*time.Millisecond)
+			},
+			[]error{ErrAborted, context.DeadlineExceeded}, []error{ErrTimeout, context.Canceled}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &pendingDriver{Sim: NewSim(), tmo: tt.tmo}
+			prev := SetDriver(p)
+			defer SetDriver(prev)
+			ctx, cancel := tt.ctx()
+			defer cancel()
+			start := time.Now()
+			rd, err := ReadAsync(ctx, 4, make([]byte, 8))
+			if err != nil {
+				t.Fatal(err)
+			}
+			n, err := rd.Wait()
+			if d := time.Since(start); d > time.Second {
+				t.Errorf("Wait returned after %v", d)
+			}
+			if n != 0 || rd.Result().Ibsta&(ERR|CMPL) != ERR|CMPL {
+				t.Errorf("Wait = %d, ibsta %v", n, rd.Result().Ibsta)
+			}
+			for _, target := range tt.want {
+				if !errors.Is(err, target) {
+					t.Errorf("err %v does not match %v", err, target)
+				}
+			}
+			for _, target := range tt.others {
+				if errors.Is(err, target) {
+					t.Errorf("err %v matches %v", err, target)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,328 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Board is a GPIB interface board, the controller side of the NI-488.2
+// multi-device routines.
+//
+// Methods taking a list of addresses act on all the devices on the bus
+// when the list is empty, as the C routines do for a list holding only
+// NOADDR.
+type Board struct {
+	id    int
+	local bool // Lock takes the in-process lock too
+}
+
+// NewBoard returns the Board for the interface board numbered boardID,
+// e.g. 0 for GPIB0.
+func NewBoard(boardID int) *Board {
+	return &Board{id: boardID}
+}
+
+// ID returns the board number.
+func (b *Board) ID() int {
+	return b.id
+}
+
+// SetTimeout sets the timeout of the board, which applies to the NI-488.2
+// routines, to the smallest timeout code of at least d, see TimeoutFor,
+// and returns the effective timeout. A d of zero disables the timeout.
+func (b *Board) SetTimeout(d time.Duration) (time.Duration, error) {
+	return setTimeout(b.id, d)
+}
+
+// Timeout returns the timeout of the board, 0 if it has none.
+func (b *Board) Timeout() (time.Duration, error) {
+	return getTimeout(b.id)
+}
+
+// Config returns the configuration of the board, see ReadConfig.
+func (b *Board) Config() (Config, error) {
+	return ReadConfig(b.id)
+}
+
+// ApplyConfig sets the options of the board that differ from c, see
+// ApplyConfig.
+func (b *Board) ApplyConfig(c Config) error {
+	return ApplyConfig(b.id, c)
+}
+
+// Lock acquires the interface lock of the board for the process, waiting
+// up to wait for another process to release it. It fails with an error
+// matching ErrLocked if the lock is still held elsewhere, and also
+// ctx.Err() if ctx is done first. See SetLocalLock.
+func (b *Board) Lock(ctx context.Context, wait time.Duration) error {
+	return lock(ctx, b.id, b.id, b.local, wait)
+}
+
+// Unlock releases the interface lock taken by Lock.
+func (b *Board) Unlock() error {
+	return unlock(b.id, b.id, b.local)
+}
+
+// SetLocalLock sets whether Lock also takes an in-process lock of the
+// interface, shared with the Board and Device values of the same board
+// that set it, so that their goroutines take turns. The in-process lock is
+// used alone when the library has no interface lock. It is not reentrant.
+func (b *Board) SetLocalLock(on bool) {
+	b.local = on
+}
+
+// SendIFC resets the bus by pulsing IFC, making the board
+// Controller-In-Charge and leaving every device unaddressed.
+func (b *Board) SendIFC() error {
+	_, err := SendIFC(b.id)
+	return err
+}
+
+// SendLLO sends the Local Lockout (LLO) message to all devices.
+func (b *Board) SendLLO() error {
+	_, err := SendLLO(b.id)
+	return err
+}
+
+// FindListeners returns the addresses of the devices present at the
+// primary addresses pads, 1 to 30 when pads is empty. A primary address
+// with no device is searched for devices at its secondary addresses.
+func (b *Board) FindListeners(pads ...Address) ([]Address, error) {
+	if len(pads) == 0 {
+		for pad := Address(1); pad <= 30; pad++ {
+			pads = append(pads, pad)
+		}
+	}
+	found, _, err := FindLstn(b.id, pads, 31*len(pads))
+	return found, err
+}
+
+// Scan finds the devices on the bus and identifies them with *IDN?, see
+// Scan.
+func (b *Board) Scan(opts *ScanOptions) ([]Listener, error) {
+	return Scan(b.id, opts)
+}
+
+// Clear sends Selected Device Clear (SDC) to the devices at addrs, or
+// Universal Device Clear (DCL) to all devices when addrs is empty.
+func (b *Board) Clear(addrs ...Address) error {
+	_, err := DevClearList(b.id, addrs)
+	return err
+}
+
+// EnableRemote asserts REN and addresses the devices at addrs to listen.
+func (b *Board) EnableRemote(addrs ...Address) error {
+	_, err := EnableRemote(b.id, addrs)
+	return err
+}
+
+// EnableLocal sends Go To Local (GTL) to the devices at addrs, or
+// unasserts REN when addrs is empty.
+func (b *Board) EnableLocal(addrs ...Address) error {
+	_, err := EnableLocal(b.id, addrs)
+	return err
+}
+
+// SetRWLS places the devices at addrs in remote with lockout state.
+func (b *Board) SetRWLS(addrs ...Address) error {
+	_, err := SetRWLS(b.id, addrs)
+	return err
+}
+
+// Trigger sends Group Execute Trigger (GET) to the devices at addrs, or
+// to the devices already listening when addrs is empty.
+func (b *Board) Trigger(addrs ...Address) error {
+	_, err := TriggerList(b.id, addrs)
+	return err
+}
+
+// ResetSys resets the bus with REN and IFC, clears every device with DCL
+// and sends "*RST\n" to the devices at addrs.
+func (b *Board) ResetSys(addrs ...Address) error {
+	_, err := ResetSys(b.id, addrs)
+	return err
+}
+
+// SelfTest is the "*TST?" response of one device, see TestSys.
+type SelfTest struct {
+	Addr Address
+	Code int16 // 0 for a pass, otherwise device specific
+}
+
+// Passed reports whether the device passed its self test.
+func (t SelfTest) Passed() bool {
+	return t.Code == 0
+}
+
+// TestSys has the devices at addrs run their self tests and returns their
+// results in the order of addrs. A device that does not answer before the
+// timeout is reported with code 1 and the error is EABO, the results of the
+// other devices are still returned.
+func (b *Board) TestSys(addrs ...Address) ([]SelfTest, error) {
+	codes, _, err := TestSys(b.id, addrs)
+	if err != nil && !errors.Is(err, ErrAborted) {
+		return nil, err
+	}
+	results := make([]SelfTest, len(addrs))
+	for i, a := range addrs {
+		results[i] = SelfTest{a, codes[i]}
+	}
+	return results, err
+}
+
+// FindRQS serial polls the devices at addrs in order and returns the
+// address and status byte of the first one requesting service. It returns
+// ErrTableFull when none is.
+func (b *Board) FindRQS(addrs ...Address) (addr Address, status byte, err error) {
+	sb, r, err := FindRQS(b.id, addrs)
+	if err != nil {
+		return NOADDR, 0, err
+	}
+	if r.Ibcntl < 0 || r.Ibcntl >= len(addrs) {
+		return NOADDR, 0, &Error{Op: "FindRQS", Ud: b.id, Sta: r.Ibsta | ERR, Code: ETAB, Count: r.Ibcntl}
+	}
+	return addrs[r.Ibcntl], byte(sb), nil
+}
+
+// AllSpoll serial polls the devices at addrs and returns their status
+// bytes in the order of addrs. If a device does not respond, the status
+// bytes of the devices polled before it are returned with the error.
+func (b *Board) AllSpoll(addrs ...Address) ([]byte, error) {
+	spr, r, err := AllSpoll(b.id, addrs)
+	n := len(addrs)
+	if err != nil {
+		n = r.count(n)
+	}
+	status := make([]byte, n)
+	for i := range status {
+		status[i] = byte(spr[i])
+	}
+	return status, err
+}
+
+// ReadStatusByte serial polls the device at addr and returns its status
+// byte.
+func (b *Board) ReadStatusByte(addr Address) (byte, error) {
+	sb, _, err := ReadStatusByte(b.id, addr)
+	return byte(sb), err
+}
+
+// Send sends data to the device at addr, ending it as eotMode says
+// (NULLend, DABend or NLend), and returns the number of bytes sent.
+func (b *Board) Send(addr Address, data []byte, eotMode int) (int, error) {
+	r, err := check("Send", b.id, driver().Send(b.id, addr, data, eotMode))
+	return r.count(len(data)), err
+}
+
+// SendList sends data to the devices at addrs, ending it as eotMode says,
+// and returns the number of bytes sent.
+func (b *Board) SendList(addrs []Address, data []byte, eotMode int) (int, error) {
+	r, err := SendList(b.id, len(data), eotMode, addrs, data)
+	return r.count(len(data)), err
+}
+
+// Receive reads from the device at addr into buf until buf is full or the
+// termination condition is met: STOPend for EOI, or an EOS character.
+// It returns the number of bytes read.
+func (b *Board) Receive(addr Address, buf []byte, termination int) (int, error) {
+	r, err := check("Receive", b.id, driver().Receive(b.id, addr, buf, termination))
+	return r.count(len(buf)), err
+}
+
+// ReceiveMessage reads a whole message from the device at addr, of any
+// length, until END: EOI for STOPend, else EOI or the EOS character
+// termination. The device is addressed once with Receive and the rest is
+// read with RcvRespMsg. On an error the bytes read so far are returned
+// along with it.
+func (b *Board) ReceiveMessage(addr Address, termination int) ([]byte, error) {
+	first := true
+	return readMessage(nil, func(p []byte) (int, bool, error) {
+		var (
+			r   Result
+			err error
+		)
+		if first {
+			first = false
+			r, err = check("Receive", b.id, driver().Receive(b.id, addr, p, termination))
+		} else {
+			r, err = check("RcvRespMsg", b.id, driver().RcvRespMsg(b.id, p, termination))
+		}
+		return r.count(len(p)), r.Ibsta.End(), err
+	})
+}
+
+// PassControl passes Controller-In-Charge to the device at addr.
+func (b *Board) PassControl(addr Address) error {
+	_, err := PassControl(b.id, addr)
+	return err
+}
+
+// PPoll conducts a parallel poll and returns the response byte, one bit
+// per data line.
+func (b *Board) PPoll() (byte, error) {
+	ppr, _, err := PPoll(b.id)
+	return byte(ppr), err
+}
+
+// PPollConfig configures the device at addr to answer parallel polls on
+// data line dataLine (1 to 8), asserting it when its ist bit equals
+// lineSense.
+func (b *Board) PPollConfig(addr Address, dataLine, lineSense int) error {
+	_, err := PPollConfig(b.id, dataLine, lineSense, addr)
+	return err
+}
+
+// PPollUnconfig unconfigures the devices at addrs for parallel polls, or
+// sends Parallel Poll Unconfigure (PPU) to all devices when addrs is empty.
+func (b *Board) PPollUnconfig(addrs ...Address) error {
+	_, err := PPollUnconfig(b.id, addrs)
+	return err
+}
+
+// ParallelPoll returns a ParallelPoll of the board, to assign devices to
+// the parallel poll data lines and find those requesting service.
+func (b *Board) ParallelPoll() *ParallelPoll {
+	return NewParallelPoll(b.id)
+}
+
+// Lines returns the state of the GPIB control lines.
+func (b *Board) Lines() (BusLines, error) {
+	return Lines(b.id)
+}
+
+// WatchLines sends the control lines whenever they change, polling them
+// every interval until ctx is done, see WatchLines.
+func (b *Board) WatchLines(ctx context.Context, interval time.Duration) (<-chan LineSample, error) {
+	return WatchLines(ctx, b.id, interval)
+}
+
+// SRQDispatcher returns an SRQDispatcher of the board, to call a handler
+// for each service request of the devices on the bus.
+func (b *Board) SRQDispatcher() *SRQDispatcher {
+	return NewSRQDispatcher(b.id)
+}
+
+// TestSRQ reports whether SRQ is asserted.
+func (b *Board) TestSRQ() (bool, error) {
+	srq, _, err := TestSRQ(b.id)
+	return srq != 0, err
+}
+
+// WaitSRQ waits for SRQ to be asserted or the board timeout to expire and
+// reports whether SRQ is asserted.
+func (b *Board) WaitSRQ() (bool, error) {
+	srq, _, err := WaitSRQ(b.id)
+	return srq != 0, err
+}
+
+// WaitSRQContext is WaitSRQ, aborted with Ibonl when ctx is done, see
+// WaitSRQContext.
+func (b *Board) WaitSRQContext(ctx context.Context) (bool, error) {
+	srq, _, err := WaitSRQContext(ctx, b.id)
+	return srq != 0, err
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// useBoard attaches instruments at pads 5 and 22 answering *TST? with 7
+// and 0, and returns them and board 0.
+func useBoard(t *testing.T) (map[int]*SimInstrument, *Board) {
+	s := useSim(t)
+	insts := make(map[int]*SimInstrument)
+	for pad, code := range map[int]string{5: "7\n", 22: "0\n"} {
+		code := code
+		insts[pad] = NewSimInstrument(func(msg string) []byte {
+			if msg == "*TST?" {
+				return []byte(code)
+			}
+			return nil
+		})
+		if err := s.Attach(0, pad, NO_SAD, insts[pad]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b := NewBoard(0)
+	if err := b.SendIFC(); err != nil {
+		t.Fatal(err)
+	}
+	return insts, b
+}
+
+func TestBoardFindListeners(t *testing.T) {
+	_, b := useBoard(t)
+	found, err := b.FindListeners(1, 5, 22)
+	if err != nil || !reflect.DeepEqual(found, []Address{5, 22}) {
+		t.Errorf("FindListeners = %v, %v; want [5 22]", found, err)
+	}
+	found, err = b.FindListeners()
+	if err != nil || !reflect.DeepEqual(found, []Address{5, 22}) {
+		t.Errorf("FindListeners() = %v, %v; want all the devices, [5 22]", found, err)
+	}
+}
+
+func TestBoardTestSys(t *testing.T) {
+	_, b := useBoard(t)
+	res, err := b.TestSys(22, 5)
+	want := []SelfTest{{22, 0}, {5, 7}}
+	if err != nil || !reflect.DeepEqual(res, want) {
+		t.Errorf("TestSys = %v, %v; want %v", res, err, want)
+	}
+	if !res[0].Passed() || res[1].Passed() {
+		t.Errorf("Passed wrong for %v", res)
+	}
+}
+
+func TestBoardSerialPoll(t *testing.T) {
+	insts, b := useBoard(t)
+	if _, _, err := b.FindRQS(22, 5); !errors.Is(err, ErrTableFull) {
+		t.Errorf("FindRQS with no request = %v, want ErrTableFull", err)
+	}
+	insts[5].RequestService(0x01)
+	if addr, sb, err := b.FindRQS(22, 5); err != nil || addr != 5 || sb != 0x41 {
+		t.Errorf("FindRQS = %v, %#x, %v; want 5, 0x41", addr, sb, err)
+	}
+	insts[22].RequestService(0x10)
+	if status, err := b.AllSpoll(5, 22); err != nil || !reflect.DeepEqual(status, []byte{0x01, 0x50}) {
+		t.Errorf("AllSpoll = %#v, %v", status, err)
+	}
+	if sb, err := b.ReadStatusByte(22); err != nil || sb != 0x10 {
+		t.Errorf("ReadStatusByte = %#x, %v; want 0x10", sb, err)
+	}
+}
+
+func TestBoardSend(t *testing.T) {
+	_, b := useBoard(t)
+	if n, err := b.Send(5, []byte("*TST?\n"), NLend); err != nil || n != 6 {
+		t.Fatalf("Send = %d, %v", n, err)
+	}
+	buf := make([]byte, 8)
+	if n, err := b.Receive(5, buf, STOPend); err != nil || string(buf[:n]) != "7\n" {
+		t.Errorf("Receive = %q, %v", buf[:n], err)
+	}
+	if n, err := b.SendList([]Address{5, 22}, []byte("*TST?\n"), NLend); err != nil || n != 6 {
+		t.Fatalf("SendList = %d, %v", n, err)
+	}
+	for _, want := range []struct {
+		addr  Address
+		reply string
+	}{{5, "7\n"}, {22, "0\n"}} {
+		if msg, err := b.ReceiveMessage(want.addr, STOPend); err != nil || string(msg) != want.reply {
+			t.Errorf("ReceiveMessage(%v) = %q, %v; want %q", want.addr, msg, err, want.reply)
+		}
+	}
+	if _, err := b.Send(9, []byte("x"), NLend); !errors.Is(err, ErrNoListeners) {
+		t.Errorf("Send to an absent device = %v, want ENOL", err)
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import "errors"
+
+// Config is a snapshot of the configuration of a board or device, one
+// field per Ibask option. A nil field is an option the board or device
+// does not have, or, for ApplyConfig, one to leave alone; fields are
+// omitted from JSON when nil, so a saved Config restores what it holds.
+// SerialNumber and BNA are read only.
+type Config struct {
+	PAD            *int       `json:",omitempty"`
+	SAD            *int       `json:",omitempty"`
+	TMO            *Timeout   `json:",omitempty"`
+	EOT            *bool      `json:",omitempty"`
+	EOS            *EOSConfig `json:",omitempty"`
+	PPC            *int       `json:",omitempty"`
+	READDR         *bool      `json:",omitempty"`
+	AUTOPOLL       *bool      `json:",omitempty"`
+	CICPROT        *bool      `json:",omitempty"`
+	IRQ            *bool      `json:",omitempty"`
+	SC             *bool      `json:",omitempty"`
+	SRE            *bool      `json:",omitempty"`
+	PP2            *bool      `json:",omitempty"`
+	TIMING         *int       `json:",omitempty"`
+	DMA            *bool      `json:",omitempty"`
+	ReadAdjust     *int       `json:",omitempty"`
+	WriteAdjust    *int       `json:",omitempty"`
+	SendLLO        *bool      `json:",omitempty"`
+	SPollTime      *Timeout   `json:",omitempty"`
+	PPollTime      *Timeout   `json:",omitempty"`
+	EndBitIsNormal *bool      `json:",omitempty"`
+	UnAddr         *bool      `json:",omitempty"`
+	BlockIfLocked  *bool      `json:",omitempty"`
+	HSCableLength  *int       `json:",omitempty"`
+	Ist            *bool      `json:",omitempty"`
+	Rsv            *int       `json:",omitempty"`
+	LON            *bool      `json:",omitempty"`
+	SerialNumber   *int       `json:",omitempty"`
+	BNA            *int       `json:",omitempty"`
+}
+
+// configField is a Config field and the options reading and setting it,
+// set is 0 for the read only ones. The fields are set in the order of
+// configFields, so SC comes before SRE, which needs it.
+type configField struct {
+	ask, set ConfigOption
+	get      func(c *Config) (v int, ok bool)
+	put      func(c *Config, v int)
+}
+
+func intField(ask, set ConfigOption, f func(c *Config) **int) configField {
+	return configField{ask, set,
+		func(c *Config) (int, bool) {
+			if p := *f(c); p != nil {
+				return *p, true
+			}
+			return 0, false
+		},
+		func(c *Config, v int) { *f(c) = &v },
+	}
+}
+
+func boolField(ask, set ConfigOption, f func(c *Config) **bool) configField {
+	return configField{ask, set,
+		func(c *Config) (int, bool) {
+			if p := *f(c); p != nil {
+				return btoi(*p), true
+			}
+			return 0, false
+		},
+		func(c *Config, v int) {
+			b := v != 0
+			*f(c) = &b
+		},
+	}
+}
+
+func timeoutField(ask, set ConfigOption, f func(c *Config) **Timeout) configField {
+	return configField{ask, set,
+		func(c *Config) (int, bool) {
+			if p := *f(c); p != nil {
+				return int(*p), true
+			}
+			return 0, false
+		},
+		func(c *Config, v int) {
+			t := Timeout(v)
+			*f(c) = &t
+		},
+	}
+}
+
+var configFields = [...]configField{
+	intField(IbaPAD, IbcPAD, func(c *Config) **int { return &c.PAD }),
+	intField(IbaSAD, IbcSAD, func(c *Config) **int { return &c.SAD }),
+	timeoutField(IbaTMO, IbcTMO, func(c *Config) **Timeout { return &c.TMO }),
+	boolField(IbaEOT, IbcEOT, func(c *Config) **bool { return &c.EOT }),
+	intField(IbaPPC, IbcPPC, func(c *Config) **int { return &c.PPC }),
+	boolField(IbaREADDR, IbcREADDR, func(c *Config) **bool { return &c.READDR }),
+	boolField(IbaAUTOPOLL, IbcAUTOPOLL, func(c *Config) **bool { return &c.AUTOPOLL }),
+	boolField(IbaCICPROT, IbcCICPROT, func(c *Config) **bool { return &c.CICPROT }),
+	boolField(IbaIRQ, IbcIRQ, func(c *Config) **bool { return &c.IRQ }),
+	boolField(IbaSC, IbcSC, func(c *Config) **bool { return &c.SC }),
+	boolField(IbaSRE, IbcSRE, func(c *Config) **bool { return &c.SRE }),
+	boolField(IbaPP2, IbcPP2, func(c *Config) **bool { return &c.PP2 }),
+	intField(IbaTIMING, IbcTIMING, func(c *Config) **int { return &c.TIMING }),
+	boolField(IbaDMA, IbcDMA, func(c *Config) **bool { return &c.DMA }),
+	intField(IbaReadAdjust, IbcReadAdjust, func(c *Config) **int { return &c.ReadAdjust }),
+	intField(IbaWriteAdjust, IbcWriteAdjust, func(c *Config) **int { return &c.WriteAdjust }),
+	boolField(IbaSendLLO, IbcSendLLO, func(c *Config) **bool { return &c.SendLLO }),
+	timeoutField(IbaSPollTime, IbcSPollTime, func(c *Config) **Timeout { return &c.SPollTime }),
+	timeoutField(IbaPPollTime, IbcPPollTime, func(c *Config) **Timeout { return &c.PPollTime }),
+	boolField(IbaEndBitIsNormal, IbcEndBitIsNormal, func(c *Config) **bool { return &c.EndBitIsNormal }),
+	boolField(IbaUnAddr, IbcUnAddr, func(c *Config) **bool { return &c.UnAddr }),
+	boolField(IbaBlockIfLocked, IbcBlockIfLocked, func(c *Config) **bool { return &c.BlockIfLocked }),
+	intField(IbaHSCableLength, IbcHSCableLength, func(c *Config) **int { return &c.HSCableLength }),
+	boolField(IbaIst, IbcIst, func(c *Config) **bool { return &c.Ist }),
+	intField(IbaRsv, IbcRsv, func(c *Config) **int { return &c.Rsv }),
+	boolField(IbaLON, IbcLON, func(c *Config) **bool { return &c.LON }),
+	intField(IbaSerialNumber, 0, func(c *Config) **int { return &c.SerialNumber }),
+	intField(IbaBNA, 0, func(c *Config) **int { return &c.BNA }),
+}
+
+// missing reports whether err is Ibask failing for an option the board
+// or device does not have.
+func missing(err error) bool {
+	return errors.Is(err, ErrCapability) || errors.Is(err, ErrArgument)
+}
+
+// ReadConfig returns the configuration of ud, asking for every option with
+// Ibask. Options ud does not have, which Ibask fails with ECAP or EARG,
+// are left nil.
+func ReadConfig(ud int) (Config, error) {
+	var c Config
+	for _, f := range configFields {
+		v, _, err := Ibask(ud, f.ask)
+		switch {
+		case err == nil:
+			f.put(&c, v)
+		case !missing(err):
+			return Config{}, err
+		}
+	}
+	eos, err := GetEOS(ud)
+	switch {
+	case err == nil:
+		c.EOS = &eos
+	case !missing(err):
+		return Config{}, err
+	}
+	return c, nil
+}
+
+// ApplyConfig sets the configuration of ud to c, making only the Ibconfig
+// calls, and the Ibeos call, for the options in c that differ from the
+// configuration of ud now. Nil and read only fields are left alone. It
+// stops at the first call that fails.
+func ApplyConfig(ud int, c Config) error {
+	cur, err := ReadConfig(ud)
+	if err != nil {
+		return err
+	}
+	for _, f := range configFields {
+		v, ok := f.get(&c)
+		if !ok || f.set == 0 {
+			continue
+		}
+		if now, ok := f.get(&cur); ok && now == v {
+			continue
+		}
+		if _, err := Ibconfig(ud, f.set, v); err != nil {
+			return err
+		}
+	}
+	if c.EOS != nil && (cur.EOS == nil || *cur.EOS != *c.EOS) {
+		return SetEOS(ud, *c.EOS)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// configCalls is a Sim recording the options set with Ibconfig, and Ibeos
+// as -1.
+type configCalls struct {
+	*Sim
+	calls []ConfigOption
+}
+
+func (c *configCalls) Ibconfig(ud int, option ConfigOption, v int) Result {
+	c.calls = append(c.calls, option)
+	return c.Sim.Ibconfig(ud, option, v)
+}
+
+func (c *configCalls) Ibeos(ud, v int) Result {
+	c.calls = append(c.calls, -1)
+	return c.Sim.Ibeos(ud, v)
+}
+
+// useConfigCalls installs a configCalls with a device at pad 22, opened
+// with T100ms, EOT and EOS '\n', for the rest of the test.
+func useConfigCalls(t *testing.T) (*configCalls, int) {
+	c := &configCalls{Sim: NewSim()}
+	prev := SetDriver(c)
+	t.Cleanup(func() { SetDriver(prev) })
+	c.Attach(0, 22, NO_SAD, NewSimInstrument(nil))
+	ud, _, err := Ibdev(0, 22, NO_SAD, T100ms, 1, '\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, ud
+}
+
+func TestReadConfig(t *testing.T) {
+	_, ud := useConfigCalls(t)
+	c, err := ReadConfig(ud)
+	if err != nil {
+		t.Fatal(err)
+	}
+	switch {
+	case c.PAD == nil || *c.PAD != 22,
+		c.SAD == nil || *c.SAD != NO_SAD,
+		c.TMO == nil || *c.TMO != T100ms,
+		c.EOT == nil || !*c.EOT,
+		c.EOS == nil || *c.EOS != EOSConfig{Char: '\n'}:
+		t.Errorf("device config %s", mustJSON(t, c))
+	}
+	if c.SC != nil {
+		t.Errorf("device config has the board option SC: %s", mustJSON(t, c))
+	}
+
+	b, err := ReadConfig(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.SC == nil || !*b.SC || b.AUTOPOLL == nil || b.PAD == nil || *b.PAD != 0 {
+		t.Errorf("board config %s", mustJSON(t, b))
+	}
+}
+
+func TestConfigJSON(t *testing.T) {
+	_, ud := useConfigCalls(t)
+	c, err := ReadConfig(ud)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := mustJSON(t, c)
+	if strings.Contains(data, `"SC"`) || strings.Contains(data, "null") {
+		t.Errorf("missing options in JSON: %s", data)
+	}
+	var back Config
+	if err := json.Unmarshal([]byte(data), &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, c) {
+		t.Errorf("round trip of %s gave %s", data, mustJSON(t, back))
+	}
+}
+
+func TestApplyConfig(t *testing.T) {
+	tmo, yes, no, sn := T1s, true, false, 1234
+	tests := []struct {
+		name  string
+		c     func(c *Config) // the change made to the config read
+		calls []ConfigOption
+	}{
+		{"unchanged", func(c *Config) {}, nil},
+		{"empty", func(c *Config) { *c = Config{} }, nil},
+		{"timeout", func(c *Config) { c.TMO = &tmo }, []ConfigOption{IbcTMO}},
+		{"timeout and EOT", func(c *Config) { c.EOT, c.TMO = &no, &tmo }, []ConfigOption{IbcTMO, IbcEOT}},
+		{"same EOT", func(c *Config) { c.EOT = &yes }, nil},
+		{"EOS", func(c *Config) { c.EOS = &EOSConfig{Char: '\r', TerminateRead: true} }, []ConfigOption{-1}},
+		{"read only", func(c *Config) { c.SerialNumber = &sn }, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls, ud := useConfigCalls(t)
+			c, err := ReadConfig(ud)
+			if err != nil {
+				t.Fatal(err)
+			}
+			before := c
+			tt.c(&c)
+			if err := ApplyConfig(ud, c); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(calls.calls, tt.calls) {
+				t.Errorf("calls %v, want %v", calls.calls, tt.calls)
+			}
+			// The options set read back, the others are unchanged.
+			after, err := ReadConfig(ud)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := before
+			if tt.calls != nil {
+				want = c
+			}
+			if !reflect.DeepEqual(after, want) {
+				t.Errorf("config %s, want %s", mustJSON(t, after), mustJSON(t, want))
+			}
+		})
+	}
+}
+
+func mustJSON(t *testing.T, c Config) string {
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
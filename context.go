@@ -0,0 +1,176 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"context"
+	"sync"
+)
+
+// The Context variants of the blocking calls abort the call when ctx is
+// done before it returns: transfers are stopped with Ibstop, and waits
+// with Ibonl(ud, 1), which also restores the configuration of ud to its
+// defaults. The call then fails with an *Error holding EABO that matches
+// both ErrAborted and ctx.Err().
+//
+// If ctx has a deadline sooner than the timeout of ud, the timeout is
+// lowered to fit it for the duration of the call, so the driver times the
+// call out itself. Calls made on ud from other goroutines meanwhile see
+// the lowered timeout too. The timeout of ud is restored once the last of
+// the calls on it with a deadline returns.
+
+// callContext makes the blocking call f as op on ud. If ctx is done before
+// f returns, stop is called from another goroutine to abort it.
+func callContext(ctx context.Context, op string, ud int, stop func(ud int) Result, f func() Result) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, aborted(op, ud, Result{}, err)
+	}
+	if ctx.Done() == nil {
+		return check(op, ud, f())
+	}
+	defer deadlineTimeout(ctx, ud)()
+	var (
+		mu                sync.Mutex
+		returned, stopped bool
+	)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			mu.Lock()
+			if !returned {
+				stopped = true
+				stop(ud)
+			}
+			mu.Unlock()
+		case <-done:
+		}
+	}()
+	r := f()
+	mu.Lock()
+	returned = true
+	mu.Unlock()
+	close(done)
+	if stopped || (r.Ibsta&TIMO != 0 && ctx.Err() != nil) {
+		return r, aborted(op, ud, r, ctx.Err())
+	}
+	return check(op, ud, r)
+}
+
+// stopIO aborts a transfer in progress on ud.
+func stopIO(ud int) Result {
+	return driver().Ibstop(ud)
+}
+
+// stopWait aborts a wait in progress on ud.
+func stopWait(ud int) Result {
+	return driver().Ibonl(ud, 1)
+}
+
+// IbrdContext is Ibrd, aborted with Ibstop when ctx is done.
+func IbrdContext(ctx context.Context, ud int, buf []byte) (r Result, err error) {
+	return callContext(ctx, "ibrd", ud, stopIO, func() Result {
+		return driver().Ibrd(ud, buf)
+	})
+}
+
+// IbwrtContext is Ibwrt, aborted with Ibstop when ctx is done.
+func IbwrtContext(ctx context.Context, ud int, buf string) (r Result, err error) {
+	return callContext(ctx, "ibwrt", ud, stopIO, func() Result {
+		return driver().Ibwrt(ud, []byte(buf))
+	})
+}
+
+// IbcmdContext is Ibcmd, aborted with Ibstop when ctx is done.
+func IbcmdContext(ctx context.Context, ud int, cmds string) (r Result, err error) {
+	return callContext(ctx, "ibcmd", ud, stopIO, func() Result {
+		return driver().Ibcmd(ud, []byte(cmds))
+	})
+}
+
+// IbrdfContext is Ibrdf, aborted with Ibstop when ctx is done.
+func IbrdfContext(ctx context.Context, ud int, filename string) (r Result, err error) {
+	return callContext(ctx, "ibrdf", ud, stopIO, func() Result {
+		return driver().Ibrdf(ud, filename)
+	})
+}
+
+// IbwrtfContext is Ibwrtf, aborted with Ibstop when ctx is done.
+func IbwrtfContext(ctx context.Context, ud int, filename string) (r Result, err error) {
+	return callContext(ctx, "ibwrtf", ud, stopIO, func() Result {
+		return driver().Ibwrtf(ud, filename)
+	})
+}
+
+// IbrspContext is Ibrsp, aborted with Ibstop when ctx is done.
+func IbrspContext(ctx context.Context, ud int) (spr byte, r Result, err error) {
+	r, err = callContext(ctx, "ibrsp", ud, stopIO, func() (r Result) {
+		spr, r = driver().Ibrsp(ud)
+		return r
+	})
+	return
+}
+
+// IbwaitContext is Ibwait, aborted with Ibonl(ud, 1) when ctx is done.
+func IbwaitContext(ctx context.Context, ud int, mask Status) (r Result, err error) {
+	return callContext(ctx, "ibwait", ud, stopWait, func() Result {
+		return driver().Ibwait(ud, mask)
+	})
+}
+
+// RcvRespMsgContext is RcvRespMsg, aborted with Ibstop when ctx is done.
+func RcvRespMsgContext(ctx context.Context, boardID, count, Termination int) (data []byte, r Result, err error) {
+	data = make([]byte, count)
+	r, err = callContext(ctx, "RcvRespMsg", boardID, stopIO, func() Result {
+		return driver().RcvRespMsg(boardID, data, Termination)
+	})
+	return data[:r.count(count)], r, err
+}
+
+// ReceiveContext is Receive, aborted with Ibstop when ctx is done.
+func ReceiveContext(ctx context.Context, boardID, count, Termination int, addr Address) (data []byte, r Result, err error) {
+	data = make([]byte, count)
+	r, err = callContext(ctx, "Receive", boardID, stopIO, func() Result {
+		return driver().Receive(boardID, addr, data, Termination)
+	})
+	return data[:r.count(count)], r, err
+}
+
+// SendContext is Send, aborted with Ibstop when ctx is done.
+func SendContext(ctx context.Context, boardID, eotMode int, addr Address, cmds string) (r Result, err error) {
+	return callContext(ctx, "Send", boardID, stopIO, func() Result {
+		return driver().Send(boardID, addr, []byte(cmds), eotMode)
+	})
+}
+
+// SendListContext is SendList, aborted with Ibstop when ctx is done.
+func SendListContext(ctx context.Context, boardID, count, eotMode int, addrlist []Address, data []byte) (r Result, err error) {
+	if count < 0 || count > len(data) {
+		return check("SendList", boardID, badCount)
+	}
+	return callContext(ctx, "SendList", boardID, stopIO, func() Result {
+		return driver().SendList(boardID, addrlist, data[:count], eotMode)
+	})
+}
+
+// ReadStatusByteContext is ReadStatusByte, aborted with Ibstop when ctx is
+// done.
+func ReadStatusByteContext(ctx context.Context, boardID int, addr Address) (result int16, r Result, err error) {
+	r, err = callContext(ctx, "ReadStatusByte", boardID, stopIO, func() (r Result) {
+		result, r = driver().ReadStatusByte(boardID, addr)
+		return r
+	})
+	return
+}
+
+// WaitSRQContext is WaitSRQ, aborted with Ibonl(boardID, 1) when ctx is
+// done.
+func WaitSRQContext(ctx context.Context, boardID int) (result int16, r Result, err error) {
+	r, err = callContext(ctx, "WaitSRQ", boardID, stopWait, func() (r Result) {
+		result, r = driver().WaitSRQ(boardID)
+		return r
+	})
+	return
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCallContextCancel(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(ctx context.Context, ud int) error
+	}{
+		{"IbrdContext", func(ctx context.Context, ud int) error {
+			_, err := IbrdContext(ctx, ud, make([]byte, 8))
+			return err
+		}},
+		{"IbwaitContext", func(ctx context.Context, ud int) error {
+			_, err := IbwaitContext(ctx, ud, RQS)
+			return err
+		}},
+		{"WaitSRQContext", func(ctx context.Context, ud int) error {
+			_, _, err := WaitSRQContext(ctx, 0)
+			return err
+		}},
+		{"ReadMessageContext", func(ctx context.Context, ud int) error {
+			_, err := ReadMessageContext(ctx, ud, nil)
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := useSim(t)
+			_, ud := simDev(t, s, TNONE, 0)
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(20*time.Millisecond, cancel)
+			start := time.Now()
+			err := tt.call(ctx, ud)
+			if d := time.Since(start); d > time.Second {
+				t.Errorf("returned after %v", d)
+			}
+			if !errors.Is(err, ErrAborted) || !errors.Is(err, context.Canceled) || errors.Is(err, ErrTimeout) {
+				t.Errorf("err %v, want EABO and context.Canceled", err)
+			}
+			// A done ctx aborts before the call is made.
+			if err := tt.call(ctx, ud); !errors.Is(err, ErrAborted) || !errors.Is(err, context.Canceled) {
+				t.Errorf("err %v with ctx done, want EABO and context.Canceled", err)
+			}
+		})
+	}
+}
+
+func TestCallContextDone(t *testing.T) {
+	s := useSim(t)
+	inst, ud := simDev(t, s, T30ms, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Calls ctx does not abort return as usual.
+	inst.Queue([]byte("ok"))
+	if r, err := IbrdContext(ctx, ud, make([]byte, 8)); err != nil || r.Ibcntl != 2 {
+		t.Errorf("IbrdContext = %+v, %v", r, err)
+	}
+	_, err := IbrdContext(ctx, ud, make([]byte, 8))
+	if !errors.Is(err, ErrTimeout) || errors.Is(err, context.Canceled) {
+		t.Errorf("IbrdContext timing out = %v, want ErrTimeout", err)
+	}
+}
+
+func TestSendListCount(t *testing.T) {
+	s := useSim(t)
+	simDev(t, s, T100ms, 0)
+	data := []byte("*RST\n")
+	for _, count := range []int{-1, len(data) + 1} {
+		if _, err := SendList(0, count, NLend, []Address{22}, data); !errors.Is(err, ErrArgument) {
+			t.Errorf("SendList with count %d = %v, want EARG", count, err)
+		}
+		_, err := SendListContext(context.Background(), 0, count, NLend, []Address{22}, data)
+		if !errors.Is(err, ErrArgument) {
+			t.Errorf("SendListContext with count %d = %v, want EARG", count, err)
+		}
+	}
+	if r, err := SendList(0, 4, DABend, []Address{22}, data); err != nil || r.Ibcntl != 4 {
+		t.Errorf("SendList of 4 bytes = %+v, %v", r, err)
+	}
+}
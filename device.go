@@ -0,0 +1,256 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Device is an open device descriptor. It implements io.ReadWriteCloser,
+// so instruments can be used with bufio, io.Copy, fmt.Fprintf and the like.
+// Read ends each message with io.EOF.
+//
+// A Device must not be read from several goroutines at once.
+type Device struct {
+	ud    int
+	local bool // Lock takes the in-process lock too
+	eof   bool // the last read was a Read that stopped on END
+}
+
+var _ io.ReadWriteCloser = (*Device)(nil)
+
+// OpenDevice opens the device at addr on board boardID with the I/O
+// timeout tmo and the EOS configuration eos. If eot is non-zero, EOI is
+// asserted with the last byte of each write. See Ibdev.
+func OpenDevice(boardID int, addr Address, tmo Timeout, eot int, eos EOSConfig) (*Device, error) {
+	ud, _, err := IbdevAddr(boardID, addr, tmo, eot, eos.Encode())
+	if err != nil {
+		return nil, err
+	}
+	return &Device{ud: ud}, nil
+}
+
+// FindDevice opens the device configured under the name udname, see Ibfind.
+func FindDevice(udname string) (*Device, error) {
+	ud, _, err := Ibfind(udname)
+	if err != nil {
+		return nil, err
+	}
+	return &Device{ud: ud}, nil
+}
+
+// NewDevice returns a Device for the device descriptor ud, as returned by
+// Ibdev or Ibfind.
+func NewDevice(ud int) *Device {
+	return &Device{ud: ud}
+}
+
+// Ud returns the device descriptor.
+func (d *Device) Ud() int {
+	return d.ud
+}
+
+// Read reads up to len(p) bytes from the device. It returns the number of
+// bytes read, which is taken from ibcntl and can be non-zero even when the
+// read fails, e.g. on a timeout.
+//
+// The Read after one that stopped on END returns 0, io.EOF without
+// reading, so io.ReadAll and io.Copy return at the end of a message. The
+// Read after that, or any other read of the Device, starts on the next
+// message.
+func (d *Device) Read(p []byte) (n int, err error) {
+	return d.ReadContext(context.Background(), p)
+}
+
+// ReadContext is Read, aborted with Ibstop when ctx is done.
+func (d *Device) ReadContext(ctx context.Context, p []byte) (n int, err error) {
+	if d.eof {
+		d.eof = false
+		return 0, io.EOF
+	}
+	n, end, err := ReadContext(ctx, d.ud, p)
+	d.eof = end && err == nil
+	return n, err
+}
+
+// ReadEnd is Read also reporting whether the read stopped on END, EOI or
+// the EOS character, which ends a message.
+func (d *Device) ReadEnd(p []byte) (n int, end bool, err error) {
+	d.eof = false
+	return Read(d.ud, p)
+}
+
+// ReadMessage reads a whole message from the device, of any length, see
+// ReadMessage.
+func (d *Device) ReadMessage() ([]byte, error) {
+	d.eof = false
+	return ReadMessage(d.ud, nil)
+}
+
+// ReadMessageContext is ReadMessage, aborted with Ibstop when ctx is done.
+func (d *Device) ReadMessageContext(ctx context.Context) ([]byte, error) {
+	d.eof = false
+	return ReadMessageContext(ctx, d.ud, nil)
+}
+
+// Write writes p to the device and returns the number of bytes written,
+// which is taken from ibcntl.
+func (d *Device) Write(p []byte) (n int, err error) {
+	return d.WriteContext(context.Background(), p)
+}
+
+// WriteContext is Write, aborted with Ibstop when ctx is done.
+func (d *Device) WriteContext(ctx context.Context, p []byte) (n int, err error) {
+	r, err := callContext(ctx, "ibwrt", d.ud, stopIO, func() Result {
+		return driver().Ibwrt(d.ud, p)
+	})
+	n = r.count(len(p))
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+	return n, err
+}
+
+// ReadTo streams a message from the device to w, see ReadTo.
+func (d *Device) ReadTo(w io.Writer, opts *StreamOptions) (int64, error) {
+	return d.ReadToContext(context.Background(), w, opts)
+}
+
+// ReadToContext is ReadTo, aborted with Ibstop when ctx is done.
+func (d *Device) ReadToContext(ctx context.Context, w io.Writer, opts *StreamOptions) (int64, error) {
+	d.eof = false
+	return ReadToContext(ctx, d.ud, w, opts)
+}
+
+// WriteFrom streams everything read from r to the device as one message,
+// see WriteFrom.
+func (d *Device) WriteFrom(r io.Reader, opts *StreamOptions) (int64, error) {
+	return WriteFromContext(context.Background(), d.ud, r, opts)
+}
+
+// WriteFromContext is WriteFrom, aborted with Ibstop when ctx is done.
+func (d *Device) WriteFromContext(ctx context.Context, r io.Reader, opts *StreamOptions) (int64, error) {
+	return WriteFromContext(ctx, d.ud, r, opts)
+}
+
+// ReadAsync starts reading up to len(p) bytes from the device, see
+// ReadAsync.
+func (d *Device) ReadAsync(ctx context.Context, p []byte) (*AsyncIO, error) {
+	d.eof = false
+	return ReadAsync(ctx, d.ud, p)
+}
+
+// WriteAsync starts writing p to the device, see WriteAsync.
+func (d *Device) WriteAsync(ctx context.Context, p []byte) (*AsyncIO, error) {
+	return WriteAsync(ctx, d.ud, p)
+}
+
+// Clear sends the Selected Device Clear (SDC) message to the device.
+func (d *Device) Clear() error {
+	_, err := Ibclr(d.ud)
+	return err
+}
+
+// Trigger sends the Group Execute Trigger (GET) message to the device.
+func (d *Device) Trigger() error {
+	_, err := Ibtrg(d.ud)
+	return err
+}
+
+// SerialPoll serial polls the device and returns its status byte.
+func (d *Device) SerialPoll() (byte, error) {
+	spr, _, err := Ibrsp(d.ud)
+	return spr, err
+}
+
+// Local sends the Go To Local (GTL) message to the device.
+func (d *Device) Local() error {
+	_, err := Ibloc(d.ud)
+	return err
+}
+
+// SetTimeout sets the I/O timeout of the device to the smallest timeout
+// code of at least d, see TimeoutFor, and returns the effective timeout.
+// A d of zero disables the timeout.
+func (d *Device) SetTimeout(timeout time.Duration) (time.Duration, error) {
+	return setTimeout(d.ud, timeout)
+}
+
+// Timeout returns the I/O timeout of the device, 0 if it has none.
+func (d *Device) Timeout() (time.Duration, error) {
+	return getTimeout(d.ud)
+}
+
+// EOS returns the EOS configuration of the device.
+func (d *Device) EOS() (EOSConfig, error) {
+	return GetEOS(d.ud)
+}
+
+// SetEOS sets the EOS configuration of the device.
+func (d *Device) SetEOS(c EOSConfig) error {
+	return SetEOS(d.ud, c)
+}
+
+// Config returns the configuration of the device, see ReadConfig.
+func (d *Device) Config() (Config, error) {
+	return ReadConfig(d.ud)
+}
+
+// ApplyConfig sets the options of the device that differ from c, see
+// ApplyConfig.
+func (d *Device) ApplyConfig(c Config) error {
+	return ApplyConfig(d.ud, c)
+}
+
+// Lock acquires the lock of the interface the device is on for the
+// process, waiting up to wait for another process to release it. It fails
+// with an error matching ErrLocked if the lock is still held elsewhere,
+// and also ctx.Err() if ctx is done first. See SetLocalLock.
+func (d *Device) Lock(ctx context.Context, wait time.Duration) error {
+	board, err := d.board()
+	if err != nil {
+		return err
+	}
+	return lock(ctx, d.ud, board, d.local, wait)
+}
+
+// Unlock releases the interface lock taken by Lock.
+func (d *Device) Unlock() error {
+	board, err := d.board()
+	if err != nil {
+		return err
+	}
+	return unlock(d.ud, board, d.local)
+}
+
+// SetLocalLock sets whether Lock also takes an in-process lock of the
+// interface, see Board.SetLocalLock.
+func (d *Device) SetLocalLock(on bool) {
+	d.local = on
+}
+
+// board returns the index of the access board of the device, which is
+// only needed for the in-process lock.
+func (d *Device) board() (int, error) {
+	if !d.local {
+		return 0, nil
+	}
+	v, _, err := Ibask(d.ud, IbaBNA)
+	return v, err
+}
+
+// Notify delivers the events in mask, e.g. RQS, CMPL or TIMO, on the
+// returned channel until ctx is done, see Notify.
+func (d *Device) Notify(ctx context.Context, mask Status) (<-chan Event, error) {
+	return Notify(ctx, d.ud, mask)
+}
+
+// Close takes the device offline and releases the descriptor.
+func (d *Device) Close() error {
+	_, err := Ibonl(d.ud, 0)
+	return err
+}
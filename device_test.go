@@ -0,0 +1,96 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func TestDeviceReadEOF(t *testing.T) {
+	s := useSim(t)
+	inst, ud := simDev(t, s, T100ms, 0)
+	d := NewDevice(ud)
+	inst.Queue([]byte("first\n"))
+	inst.Queue([]byte("second\n"))
+
+	// ReadAll returns at the end of each message.
+	for _, want := range []string{"first\n", "second\n"} {
+		got, err := ioutil.ReadAll(d)
+		if err != nil || string(got) != want {
+			t.Errorf("ReadAll = %q, %v; want %q", got, err, want)
+		}
+	}
+	if _, err := ioutil.ReadAll(d); !errors.Is(err, ErrTimeout) {
+		t.Errorf("ReadAll with no message = %v, want a timeout", err)
+	}
+
+	inst.Queue([]byte("1\n2\n"))
+	sc := bufio.NewScanner(d)
+	var lines []string
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	if sc.Err() != nil || len(lines) != 2 || lines[0] != "1" || lines[1] != "2" {
+		t.Errorf("scanned %q, %v", lines, sc.Err())
+	}
+
+	// A failed read ends no message.
+	if n, err := d.Read(make([]byte, 8)); n != 0 || !errors.Is(err, ErrTimeout) {
+		t.Errorf("Read = %d, %v; want a timeout", n, err)
+	}
+	inst.Queue([]byte("x"))
+	if n, err := d.Read(make([]byte, 8)); n != 1 || err != nil {
+		t.Errorf("Read = %d, %v; want the next message", n, err)
+	}
+}
+
+func TestDeviceReadMixed(t *testing.T) {
+	s := useSim(t)
+	inst, ud := simDev(t, s, T100ms, 0)
+	d := NewDevice(ud)
+	buf := make([]byte, 8)
+	reads := []struct {
+		name string
+		read func() (string, error)
+	}{
+		{"ReadMessage", func() (string, error) {
+			msg, err := d.ReadMessage()
+			return string(msg), err
+		}},
+		{"ReadEnd", func() (string, error) {
+			n, _, err := d.ReadEnd(buf)
+			return string(buf[:n]), err
+		}},
+		{"ReadTo", func() (string, error) {
+			var b bytes.Buffer
+			_, err := d.ReadTo(&b, nil)
+			return b.String(), err
+		}},
+	}
+	for _, r := range reads {
+		// A Read ending on END, then another read, leave no io.EOF
+		// pending for the message after.
+		inst.Queue([]byte("a"))
+		inst.Queue([]byte("b"))
+		inst.Queue([]byte("c"))
+		if n, err := d.Read(buf); err != nil || string(buf[:n]) != "a" {
+			t.Errorf("Read = %q, %v; want \"a\"", buf[:n], err)
+		}
+		if got, err := r.read(); err != nil || got != "b" {
+			t.Errorf("%s = %q, %v; want \"b\"", r.name, got, err)
+		}
+		if n, err := d.Read(buf); err != nil || string(buf[:n]) != "c" {
+			t.Errorf("Read after %s = %q, %v; want \"c\"", r.name, buf[:n], err)
+		}
+		if n, err := d.Read(buf); n != 0 || err != io.EOF {
+			t.Errorf("Read at the end of \"c\" = %d, %v; want io.EOF", n, err)
+		}
+	}
+}
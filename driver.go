@@ -0,0 +1,142 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import "sync"
+
+// Driver is the backend behind every exported NI-488 and NI-488.2 call in
+// the package. The methods are one-to-one with the C routines declared in
+// ni4882.h and ni488.h, with Go slices in place of pointer/count pairs and
+// Go strings in place of both the A (ANSI) and W (wide) name variants.
+// Routines that a library lacks fail with ECAP.
+//
+// Every method returns the Result of its call, ibsta, iberr and ibcntl as
+// they were right after it. Backends must capture them atomically with the
+// call, since the thread-specific copies can be overwritten by the next call
+// made on the same OS thread.
+//
+// Address lists passed to a Driver are not NOADDR terminated, an empty list
+// stands for a list holding only NOADDR.
+//
+// Ibrda, Ibwrta and Ibcmda can return before the transfer is done. The
+// backend keeps what it needs of buf until the I/O completes, as reported
+// by Ibwait with CMPL, Ibstop, Ibonl or an Ibnotify callback, and only
+// then stores the data read in buf.
+//
+// Ibnotify replaces any callback already installed on ud, and calls f from
+// another goroutine or thread, re-arming with the mask f returns.
+type Driver interface {
+	// NI-488 functions
+	Ibask(ud int, option ConfigOption) (v int, r Result)
+	Ibbna(ud int, udname string) Result
+	Ibcac(ud, v int) Result
+	Ibclr(ud int) Result
+	Ibcmd(ud int, cmds []byte) Result
+	Ibcmda(ud int, cmds []byte) Result
+	Ibconfig(ud int, option ConfigOption, v int) Result
+	Ibdev(boardID, pad, sad int, tmo Timeout, eot, eos int) (ud int, r Result)
+	Ibdiag(ud int, buf []byte) Result
+	Ibeos(ud, v int) Result
+	Ibexpert(ud, option int, input, output []byte) Result
+	Ibfind(udname string) (ud int, r Result)
+	Ibgts(ud, v int) Result
+	Iblck(ud, v int, lockWaitTime uint) Result
+	Iblines(ud int) (lines int16, r Result)
+	Ibln(ud, pad, sad int) (listen int16, r Result)
+	Ibloc(ud int) Result
+	Iblock(ud int) Result
+	Iblockx(ud, lockWaitTime int, lockShareName string) Result
+	Ibnotify(ud int, mask Status, f NotifyFunc) Result
+	Ibonl(ud, v int) Result
+	Ibpct(ud int) Result
+	Ibpoke(ud, option, v int) Result
+	Ibppc(ud, v int) Result
+	Ibrd(ud int, buf []byte) Result
+	Ibrda(ud int, buf []byte) Result
+	Ibrdf(ud int, filename string) Result
+	Ibrpp(ud int) (ppr byte, r Result)
+	Ibrsp(ud int) (spr byte, r Result)
+	Ibsic(ud int) Result
+	Ibstop(ud int) Result
+	Ibtrg(ud int) Result
+	Ibunlock(ud int) Result
+	Ibunlockx(ud int) Result
+	Ibwait(ud int, mask Status) Result
+	Ibwrt(ud int, buf []byte) Result
+	Ibwrta(ud int, buf []byte) Result
+	Ibwrtf(ud int, filename string) Result
+
+	// Process-wide and thread-specific copies of the GPIB global vars
+	Ibsta() uint32
+	Iberr() uint32
+	Ibcntl() uint32
+	ThreadIbsta() uint32
+	ThreadIberr() uint32
+	ThreadIbcntl() uint32
+
+	// NI-488.2 functions
+	AllSpoll(boardID int, addrlist []Address, results []int16) Result
+	DevClear(boardID int, addr Address) Result
+	DevClearList(boardID int, addrlist []Address) Result
+	EnableLocal(boardID int, addrlist []Address) Result
+	EnableRemote(boardID int, addrlist []Address) Result
+	FindLstn(boardID int, addrlist, results []Address) Result
+	FindRQS(boardID int, addrlist []Address) (status int16, r Result)
+	PPoll(boardID int) (result int16, r Result)
+	PPollConfig(boardID int, addr Address, dataLine, lineSense int) Result
+	PPollUnconfig(boardID int, addrlist []Address) Result
+	PassControl(boardID int, addr Address) Result
+	RcvRespMsg(boardID int, buf []byte, termination int) Result
+	ReadStatusByte(boardID int, addr Address) (result int16, r Result)
+	Receive(boardID int, addr Address, buf []byte, termination int) Result
+	ReceiveSetup(boardID int, addr Address) Result
+	ResetSys(boardID int, addrlist []Address) Result
+	Send(boardID int, addr Address, data []byte, eotMode int) Result
+	SendCmds(boardID int, cmds []byte) Result
+	SendDataBytes(boardID int, data []byte, eotMode int) Result
+	SendIFC(boardID int) Result
+	SendLLO(boardID int) Result
+	SendList(boardID int, addrlist []Address, data []byte, eotMode int) Result
+	SendSetup(boardID int, addrlist []Address) Result
+	SetRWLS(boardID int, addrlist []Address) Result
+	TestSRQ(boardID int) (result int16, r Result)
+	TestSys(boardID int, addrlist []Address, results []int16) Result
+	Trigger(boardID int, addr Address) Result
+	TriggerList(boardID int, addrlist []Address) Result
+	WaitSRQ(boardID int) (result int16, r Result)
+}
+
+var (
+	drvMu sync.RWMutex
+	drv   Driver
+)
+
+// SetDriver installs d as the backend used by every function in the
+// package and returns the previously installed driver. Builds with cgo
+// enabled start out with the NI-488.2 library driver; builds tagged
+// nogpib start out with no driver at all.
+func SetDriver(d Driver) (prev Driver) {
+	drvMu.Lock()
+	prev, drv = drv, d
+	drvMu.Unlock()
+	return
+}
+
+// CurrentDriver returns the backend currently in use.
+func CurrentDriver() Driver {
+	drvMu.RLock()
+	d := drv
+	drvMu.RUnlock()
+	return d
+}
+
+// driver returns the installed backend and panics if there is none.
+func driver() Driver {
+	d := CurrentDriver()
+	if d == nil {
+		panic("ni488: no driver installed, see SetDriver")
+	}
+	return d
+}
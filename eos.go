@@ -0,0 +1,68 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+// EOSConfig is the end-of-string configuration of a board or device, the
+// packed eos value taken by Ibeos and Ibdev.
+type EOSConfig struct {
+	Char          byte // the EOS character
+	TerminateRead bool // REOS: end reads on the EOS character
+	SendEOI       bool // XEOS: assert EOI when writing the EOS character
+	EightBit      bool // BIN: compare all 8 bits, not just the low 7
+}
+
+// Encode returns c packed as the eos value of Ibeos and Ibdev.
+func (c EOSConfig) Encode() int {
+	v := int(c.Char)
+	if c.TerminateRead {
+		v |= REOS
+	}
+	if c.SendEOI {
+		v |= XEOS
+	}
+	if c.EightBit {
+		v |= BIN
+	}
+	return v
+}
+
+// DecodeEOS unpacks an eos value as taken by Ibeos and Ibdev.
+func DecodeEOS(v int) EOSConfig {
+	return EOSConfig{
+		Char:          byte(v),
+		TerminateRead: v&REOS != 0,
+		SendEOI:       v&XEOS != 0,
+		EightBit:      v&BIN != 0,
+	}
+}
+
+// GetEOS returns the EOS configuration of ud, asked for with Ibask.
+func GetEOS(ud int) (EOSConfig, error) {
+	var err error
+	ask := func(option ConfigOption) int {
+		if err != nil {
+			return 0
+		}
+		var v int
+		v, _, err = Ibask(ud, option)
+		return v
+	}
+	c := EOSConfig{
+		Char:          byte(ask(IbaEOSchar)),
+		TerminateRead: ask(IbaEOSrd) != 0,
+		SendEOI:       ask(IbaEOSwrt) != 0,
+		EightBit:      ask(IbaEOScmp) != 0,
+	}
+	if err != nil {
+		return EOSConfig{}, err
+	}
+	return c, nil
+}
+
+// SetEOS sets the EOS configuration of ud with Ibeos.
+func SetEOS(ud int, c EOSConfig) error {
+	_, err := Ibeos(ud, c.Encode())
+	return err
+}
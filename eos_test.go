@@ -0,0 +1,47 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import "testing"
+
+func TestEOSEncode(t *testing.T) {
+	tests := []struct {
+		c EOSConfig
+		v int
+	}{
+		{EOSConfig{}, 0},
+		{EOSConfig{Char: '\n'}, '\n'},
+		{EOSConfig{Char: '\n', TerminateRead: true}, '\n' | REOS},
+		{EOSConfig{Char: '\r', SendEOI: true}, '\r' | XEOS},
+		{EOSConfig{Char: 0xFF, TerminateRead: true, SendEOI: true, EightBit: true}, 0xFF | REOS | XEOS | BIN},
+	}
+	for _, tt := range tests {
+		if v := tt.c.Encode(); v != tt.v {
+			t.Errorf("%+v.Encode() = %#x, want %#x", tt.c, v, tt.v)
+		}
+		if c := DecodeEOS(tt.v); c != tt.c {
+			t.Errorf("DecodeEOS(%#x) = %+v, want %+v", tt.v, c, tt.c)
+		}
+	}
+}
+
+func TestSetEOS(t *testing.T) {
+	s := useSim(t)
+	c := EOSConfig{Char: '\n', TerminateRead: true, EightBit: true}
+	_, ud := simDev(t, s, T100ms, c.Encode())
+	if got, err := GetEOS(ud); err != nil || got != c {
+		t.Errorf("GetEOS = %+v, %v; want %+v", got, err, c)
+	}
+	c = EOSConfig{Char: '\r', SendEOI: true}
+	if err := SetEOS(ud, c); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := GetEOS(ud); err != nil || got != c {
+		t.Errorf("GetEOS after SetEOS = %+v, %v; want %+v", got, err, c)
+	}
+	if _, err := GetEOS(99); err == nil {
+		t.Error("GetEOS of an invalid descriptor did not fail")
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorCode is an iberr value. It is meaningful only when ERR is set in
+// ibsta.
+type ErrorCode uint32
+
+// Sentinel errors, one per iberr code. They match the Error of a failed
+// call with errors.Is, e.g. errors.Is(err, ni488.ErrNoListeners).
+var (
+	ErrSystem         error = codeError(EDVR)
+	ErrNotCIC         error = codeError(ECIC)
+	ErrNoListeners    error = codeError(ENOL)
+	ErrNotAddressed   error = codeError(EADR)
+	ErrArgument       error = codeError(EARG)
+	ErrNotSAC         error = codeError(ESAC)
+	ErrAborted        error = codeError(EABO)
+	ErrNoBoard        error = codeError(ENEB)
+	ErrDMA            error = codeError(EDMA)
+	ErrInProgress     error = codeError(EOIP)
+	ErrCapability     error = codeError(ECAP)
+	ErrFileSystem     error = codeError(EFSO)
+	ErrBus            error = codeError(EBUS)
+	ErrStatusByteLost error = codeError(ESTB)
+	ErrSRQStuck       error = codeError(ESRQ)
+	ErrTableFull      error = codeError(ETAB)
+	ErrLocked         error = codeError(ELCK)
+	ErrRearm          error = codeError(EARM)
+	ErrHandle         error = codeError(EHDL)
+	ErrConfig         error = codeError(WCFG)
+	ErrWaitInProgress error = codeError(EWIP)
+	ErrReset          error = codeError(ERST)
+	ErrPower          error = codeError(EPWR)
+)
+
+// ErrTimeout matches the Error of any call that failed with TIMO set in
+// ibsta, in which case iberr is EABO.
+var ErrTimeout = errors.New("ni488: timeout")
+
+var errorMessages = map[ErrorCode]string{
+	EDVR: "system error",
+	ECIC: "function requires GPIB board to be CIC",
+	ENOL: "no listeners on the GPIB",
+	EADR: "GPIB board not addressed correctly",
+	EARG: "invalid argument to function call",
+	ESAC: "GPIB board not System Controller as required",
+	EABO: "I/O operation aborted",
+	ENEB: "nonexistent GPIB board",
+	EDMA: "DMA error",
+	EOIP: "asynchronous I/O in progress",
+	ECAP: "no capability for operation",
+	EFSO: "file system error",
+	EBUS: "GPIB bus error",
+	ESTB: "serial poll status byte queue overflow",
+	ESRQ: "SRQ stuck in ON position",
+	ETAB: "table problem",
+	ELCK: "interface or address is locked",
+	EARM: "ibnotify callback failed to rearm",
+	EHDL: "input handle is invalid",
+	WCFG: "configuration warning",
+	EWIP: "wait already in progress on descriptor",
+	ERST: "event notification cancelled by an interface reset",
+	EPWR: "system or board lost power or went to standby",
+}
+
+// Message returns a description of the code.
+func (c ErrorCode) Message() string {
+	if msg, ok := errorMessages[c]; ok {
+		return msg
+	}
+	return "unknown error"
+}
+
+// codeError is the type of the sentinel errors. It is distinct from
+// ErrorCode so that an iberr value formats as its name, e.g. EDVR, and is
+// not mistaken for an error when ERR is clear.
+type codeError ErrorCode
+
+func (c codeError) Error() string {
+	return "ni488: " + ErrorCode(c).String() + ": " + ErrorCode(c).Message()
+}
+
+// Error describes a failed NI-488 or NI-488.2 call.
+type Error struct {
+	Op    string    // the C function, e.g. "ibwrt" or "SendList"
+	Ud    int       // the descriptor, or board index for NI-488.2 routines
+	Sta   Status    // ibsta
+	Code  ErrorCode // iberr
+	Count int       // ibcntl, the system error for EDVR and EFSO
+	Err   error     // why the call was aborted, e.g. context.Canceled
+}
+
+func (e *Error) Error() string {
+	s := fmt.Sprintf("ni488: %s(%d): %s: %s [%v]", e.Op, e.Ud, e.Code.String(), e.Code.Message(), e.Sta)
+	if e.Err != nil {
+		s += ": " + e.Err.Error()
+	}
+	return s
+}
+
+// Unwrap returns the reason the call was aborted, if any.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the sentinel of the error code, or is
+// ErrTimeout and the call timed out.
+func (e *Error) Is(target error) bool {
+	if c, ok := target.(codeError); ok {
+		return ErrorCode(c) == e.Code
+	}
+	return target == ErrTimeout && e.Sta&TIMO != 0
+}
+
+// check returns r and, if ERR is set in it, an *Error for the call op
+// made on ud.
+func check(op string, ud int, r Result) (Result, error) {
+	if r.Ibsta&ERR == 0 {
+		return r, nil
+	}
+	return r, &Error{
+		Op:    op,
+		Ud:    ud,
+		Sta:   r.Ibsta,
+		Code:  r.Iberr,
+		Count: r.Ibcntl,
+	}
+}
+
+// aborted returns the EABO Error of the call op made on ud, aborted
+// because of cause.
+func aborted(op string, ud int, r Result, cause error) error {
+	return &Error{
+		Op:    op,
+		Ud:    ud,
+		Sta:   r.Ibsta | ERR,
+		Code:  EABO,
+		Count: r.Ibcntl,
+		Err:   cause,
+	}
+}
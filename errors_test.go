@@ -0,0 +1,61 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorCodeFormat(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		want string
+	}{
+		{EDVR, "EDVR"},
+		{ENOL, "ENOL"},
+		{Result{Ibsta: CMPL}, "{Ibsta:CMPL Iberr:EDVR Ibcntl:0}"},
+		{ErrNoListeners, "ni488: ENOL: no listeners on the GPIB"},
+	}
+	for _, tt := range tests {
+		if got := fmt.Sprintf("%+v", tt.v); got != tt.want {
+			t.Errorf("Sprintf(%%+v, %#v) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+	var v interface{} = EDVR
+	if _, ok := v.(error); ok {
+		t.Error("ErrorCode implements error")
+	}
+}
+
+func TestErrorIs(t *testing.T) {
+	timeout := &Error{Op: "ibrd", Sta: ERR | TIMO | CMPL, Code: EABO}
+	canceled := aborted("ibrd", 0, Result{Ibsta: CMPL}, context.Canceled)
+	tests := []struct {
+		err    error
+		target error
+		want   bool
+	}{
+		{&Error{Op: "ibwrt", Sta: ERR, Code: ENOL}, ErrNoListeners, true},
+		{&Error{Op: "ibwrt", Sta: ERR, Code: ENOL}, ErrNotCIC, false},
+		{&Error{Op: "ibwrt", Sta: ERR, Code: EDVR}, ErrSystem, true},
+		{fmt.Errorf("write: %w", &Error{Sta: ERR, Code: ELCK}), ErrLocked, true},
+		{timeout, ErrTimeout, true},
+		{timeout, ErrAborted, true},
+		{timeout, context.Canceled, false},
+		{canceled, ErrAborted, true},
+		{canceled, context.Canceled, true},
+		{canceled, ErrTimeout, false},
+		{ErrAborted, ErrAborted, true},
+		{ErrAborted, ErrTimeout, false},
+	}
+	for _, tt := range tests {
+		if got := errors.Is(tt.err, tt.target); got != tt.want {
+			t.Errorf("errors.Is(%v, %v) = %v, want %v", tt.err, tt.target, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,590 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+//go:build fakegpib && amd64
+// +build fakegpib,amd64
+
+// A stand-in for the NI-488.2 library implementing the ni4882.h ABI.
+// Every call is recorded and returns the status scripted for it, so the
+// cgo wrappers can be exercised without NI hardware or drivers. It is
+// driven from Go through the functions in ni_fake.go.
+
+#include <pthread.h>
+#include <stdarg.h>
+#include <stdlib.h>
+#include <string.h>
+#include <ni4882.h>
+
+#define FAKE_MAXCALLS 256
+#define FAKE_MAXARGS  6
+#define FAKE_MAXDATA  1024
+#define FAKE_MAXLIST  32
+#define FAKE_MAXFUNCS 64
+#define FAKE_NAMELEN  16
+
+typedef struct {
+	char   fn[FAKE_NAMELEN];
+	long   args[FAKE_MAXARGS];
+	int    nargs;
+	char   data[FAKE_MAXDATA];
+	size_t ndata;
+	short  list[FAKE_MAXLIST];
+	int    nlist;
+} fake_call;
+
+typedef struct {
+	char          fn[FAKE_NAMELEN];
+	int           set;
+	unsigned long sta, err, cntl;
+	short         out[FAKE_MAXLIST];
+	int           nout;
+} fake_func;
+
+static pthread_mutex_t mu = PTHREAD_MUTEX_INITIALIZER;
+static fake_call calls[FAKE_MAXCALLS];
+static int       ncalls;
+static fake_call overflow;
+static fake_func funcs[FAKE_MAXFUNCS];
+static int       nfuncs;
+
+static char   readbuf[FAKE_MAXDATA];
+static size_t nread, readpos;
+
+static GpibNotifyCallback_t notify_cb;
+static int                  notify_ud;
+static void *               notify_ref;
+
+unsigned long ibsta, iberr, ibcnt, ibcntl;
+static __thread unsigned long t_sta, t_err, t_cnt;
+
+void fake_reset(void) {
+	memset(calls, 0, sizeof(calls));
+	ncalls = 0;
+	memset(funcs, 0, sizeof(funcs));
+	nfuncs = 0;
+	nread = readpos = 0;
+	notify_cb = NULL;
+	notify_ud = 0;
+	notify_ref = NULL;
+	ibsta = iberr = ibcnt = ibcntl = 0;
+	t_sta = t_err = t_cnt = 0;
+}
+
+static fake_func *lookup(const char *fn, int create) {
+	int i;
+	for (i = 0; i < nfuncs; i++) {
+		if (strncmp(funcs[i].fn, fn, FAKE_NAMELEN) == 0)
+			return &funcs[i];
+	}
+	if (!create || nfuncs == FAKE_MAXFUNCS)
+		return NULL;
+	strncpy(funcs[nfuncs].fn, fn, FAKE_NAMELEN - 1);
+	return &funcs[nfuncs++];
+}
+
+void fake_set_result(const char *fn, unsigned long sta, unsigned long err, unsigned long cntl) {
+	fake_func *f = lookup(fn, 1);
+	if (f == NULL)
+		return;
+	f->set = 1;
+	f->sta = sta;
+	f->err = err;
+	f->cntl = cntl;
+}
+
+void fake_set_output(const char *fn, const short *vals, int n) {
+	fake_func *f = lookup(fn, 1);
+	if (f == NULL)
+		return;
+	if (n > FAKE_MAXLIST)
+		n = FAKE_MAXLIST;
+	memcpy(f->out, vals, n * sizeof(short));
+	f->nout = n;
+}
+
+void fake_set_read(const void *data, size_t n) {
+	if (n > FAKE_MAXDATA)
+		n = FAKE_MAXDATA;
+	memcpy(readbuf, data, n);
+	nread = n;
+	readpos = 0;
+}
+
+int fake_ncalls(void) {
+	return ncalls;
+}
+
+// fake_call_info copies out call i and returns the number of its
+// arguments, or -1 if there is no such call.
+int fake_call_info(int i, char *fn, long *args, char *data, size_t *ndata, short *list, int *nlist) {
+	fake_call *c;
+	if (i < 0 || i >= ncalls)
+		return -1;
+	c = &calls[i];
+	memcpy(fn, c->fn, FAKE_NAMELEN);
+	memcpy(args, c->args, sizeof(c->args));
+	*ndata = c->ndata;
+	memcpy(data, c->data, c->ndata < FAKE_MAXDATA ? c->ndata : FAKE_MAXDATA);
+	memcpy(list, c->list, sizeof(c->list));
+	*nlist = c->nlist;
+	return c->nargs;
+}
+
+// fake_notify invokes the callback installed by ibnotify. It returns 0
+// if there is none, otherwise 1 with the callback's new mask in mask.
+int fake_notify(unsigned long sta, unsigned long err, unsigned long cntl, int *mask) {
+	if (notify_cb == NULL)
+		return 0;
+	*mask = notify_cb(notify_ud, sta, err, cntl, notify_ref);
+	return 1;
+}
+
+static fake_call *record(const char *fn, int nargs, ...) {
+	fake_call *c;
+	va_list ap;
+	int i;
+	pthread_mutex_lock(&mu);
+	c = ncalls < FAKE_MAXCALLS ? &calls[ncalls++] : &overflow;
+	pthread_mutex_unlock(&mu);
+	memset(c, 0, sizeof(*c));
+	strncpy(c->fn, fn, FAKE_NAMELEN - 1);
+	va_start(ap, nargs);
+	for (i = 0; i < nargs && i < FAKE_MAXARGS; i++)
+		c->args[i] = va_arg(ap, long);
+	va_end(ap);
+	c->nargs = nargs;
+	return c;
+}
+
+static void data(fake_call *c, const void *buf, size_t n) {
+	c->ndata = n;
+	if (buf != NULL)
+		memcpy(c->data, buf, n < FAKE_MAXDATA ? n : FAKE_MAXDATA);
+}
+
+static void list(fake_call *c, const short *addrs) {
+	int n = 0;
+	while (addrs != NULL && n < FAKE_MAXLIST && addrs[n] != NOADDR) {
+		c->list[n] = addrs[n];
+		n++;
+	}
+	c->nlist = n;
+}
+
+// finish sets the status variables to the result scripted for fn, or to
+// CMPL|extra with an ibcntl of cntl, and returns ibsta.
+static unsigned long finish(const char *fn, unsigned long extra, unsigned long cntl) {
+	fake_func *f = lookup(fn, 0);
+	if (f != NULL && f->set) {
+		t_sta = f->sta;
+		t_err = f->err;
+		t_cnt = f->cntl;
+	} else {
+		t_sta = CMPL | extra;
+		t_err = 0;
+		t_cnt = cntl;
+	}
+	ibsta = t_sta;
+	iberr = t_err;
+	ibcnt = ibcntl = t_cnt;
+	return t_sta;
+}
+
+static short out(const char *fn, int i, short def) {
+	fake_func *f = lookup(fn, 0);
+	if (f == NULL || i >= f->nout)
+		return def;
+	return f->out[i];
+}
+
+// readdata copies the scripted read data into buf and reports whether
+// all of it has been read.
+static size_t readdata(void *buf, size_t cnt, int *end) {
+	size_t n = nread - readpos;
+	if (n > cnt)
+		n = cnt;
+	if (buf != NULL)
+		memcpy(buf, readbuf + readpos, n);
+	readpos += n;
+	*end = readpos == nread;
+	return n;
+}
+
+//
+// NI-488 functions
+//
+
+unsigned long ibask(int ud, int option, int *v) {
+	record("ibask", 2, (long)ud, (long)option);
+	*v = out("ibask", 0, 0);
+	return finish("ibask", 0, 0);
+}
+
+unsigned long ibcac(int ud, int v) {
+	record("ibcac", 2, (long)ud, (long)v);
+	return finish("ibcac", 0, 0);
+}
+
+unsigned long ibclr(int ud) {
+	record("ibclr", 1, (long)ud);
+	return finish("ibclr", 0, 0);
+}
+
+unsigned long ibcmd(int ud, const void *buf, size_t cnt) {
+	data(record("ibcmd", 2, (long)ud, (long)cnt), buf, cnt);
+	return finish("ibcmd", 0, cnt);
+}
+
+unsigned long ibcmda(int ud, const void *buf, size_t cnt) {
+	data(record("ibcmda", 2, (long)ud, (long)cnt), buf, cnt);
+	return finish("ibcmda", 0, cnt);
+}
+
+unsigned long ibconfig(int ud, int option, int v) {
+	record("ibconfig", 3, (long)ud, (long)option, (long)v);
+	return finish("ibconfig", 0, 0);
+}
+
+int ibdev(int boardID, int pad, int sad, int tmo, int eot, int eos) {
+	record("ibdev", 6, (long)boardID, (long)pad, (long)sad, (long)tmo, (long)eot, (long)eos);
+	finish("ibdev", 0, 0);
+	return out("ibdev", 0, 1);
+}
+
+unsigned long ibexpert(int ud, int option, const void *Input, void *Output) {
+	record("ibexpert", 2, (long)ud, (long)option);
+	return finish("ibexpert", 0, 0);
+}
+
+int ibfindA(const char *udname) {
+	fake_call *c = record("ibfindA", 0);
+	data(c, udname, strlen(udname));
+	finish("ibfindA", 0, 0);
+	return out("ibfindA", 0, 0);
+}
+
+int ibfindW(const wchar_t *udname) {
+	record("ibfindW", 0);
+	finish("ibfindW", 0, 0);
+	return out("ibfindW", 0, 0);
+}
+
+unsigned long ibgts(int ud, int v) {
+	record("ibgts", 2, (long)ud, (long)v);
+	return finish("ibgts", 0, 0);
+}
+
+unsigned long iblck(int ud, int v, unsigned int LockWaitTime, void *Reserved) {
+	record("iblck", 3, (long)ud, (long)v, (long)LockWaitTime);
+	return finish("iblck", 0, 0);
+}
+
+unsigned long iblines(int ud, short *result) {
+	record("iblines", 1, (long)ud);
+	*result = out("iblines", 0, 0);
+	return finish("iblines", 0, 0);
+}
+
+unsigned long ibln(int ud, int pad, int sad, short *listen) {
+	record("ibln", 3, (long)ud, (long)pad, (long)sad);
+	*listen = out("ibln", 0, 0);
+	return finish("ibln", 0, 0);
+}
+
+unsigned long ibloc(int ud) {
+	record("ibloc", 1, (long)ud);
+	return finish("ibloc", 0, 0);
+}
+
+unsigned long ibnotify(int ud, int mask, GpibNotifyCallback_t Callback, void *RefData) {
+	record("ibnotify", 2, (long)ud, (long)mask);
+	notify_ud = ud;
+	notify_cb = mask != 0 ? Callback : NULL;
+	notify_ref = RefData;
+	return finish("ibnotify", 0, 0);
+}
+
+unsigned long ibonl(int ud, int v) {
+	record("ibonl", 2, (long)ud, (long)v);
+	return finish("ibonl", 0, 0);
+}
+
+unsigned long ibpct(int ud) {
+	record("ibpct", 1, (long)ud);
+	return finish("ibpct", 0, 0);
+}
+
+unsigned long ibppc(int ud, int v) {
+	record("ibppc", 2, (long)ud, (long)v);
+	return finish("ibppc", 0, 0);
+}
+
+unsigned long ibrd(int ud, void *buf, size_t cnt) {
+	int end;
+	size_t n;
+	record("ibrd", 2, (long)ud, (long)cnt);
+	n = readdata(buf, cnt, &end);
+	return finish("ibrd", end ? END : 0, n);
+}
+
+unsigned long ibrda(int ud, void *buf, size_t cnt) {
+	int end;
+	size_t n;
+	record("ibrda", 2, (long)ud, (long)cnt);
+	n = readdata(buf, cnt, &end);
+	return finish("ibrda", end ? END : 0, n);
+}
+
+unsigned long ibrdfA(int ud, const char *filename) {
+	data(record("ibrdfA", 1, (long)ud), filename, strlen(filename));
+	return finish("ibrdfA", 0, 0);
+}
+
+unsigned long ibrdfW(int ud, const wchar_t *filename) {
+	record("ibrdfW", 1, (long)ud);
+	return finish("ibrdfW", 0, 0);
+}
+
+unsigned long ibrpp(int ud, char *ppr) {
+	record("ibrpp", 1, (long)ud);
+	*ppr = (char)out("ibrpp", 0, 0);
+	return finish("ibrpp", 0, 0);
+}
+
+unsigned long ibrsp(int ud, char *spr) {
+	record("ibrsp", 1, (long)ud);
+	*spr = (char)out("ibrsp", 0, 0);
+	return finish("ibrsp", 0, 1);
+}
+
+unsigned long ibsic(int ud) {
+	record("ibsic", 1, (long)ud);
+	return finish("ibsic", 0, 0);
+}
+
+unsigned long ibstop(int ud) {
+	record("ibstop", 1, (long)ud);
+	return finish("ibstop", 0, 0);
+}
+
+unsigned long ibtrg(int ud) {
+	record("ibtrg", 1, (long)ud);
+	return finish("ibtrg", 0, 0);
+}
+
+unsigned long ibwait(int ud, int mask) {
+	record("ibwait", 2, (long)ud, (long)mask);
+	return finish("ibwait", 0, 0);
+}
+
+unsigned long ibwrt(int ud, const void *buf, size_t cnt) {
+	data(record("ibwrt", 2, (long)ud, (long)cnt), buf, cnt);
+	return finish("ibwrt", 0, cnt);
+}
+
+unsigned long ibwrta(int ud, const void *buf, size_t cnt) {
+	data(record("ibwrta", 2, (long)ud, (long)cnt), buf, cnt);
+	return finish("ibwrta", 0, cnt);
+}
+
+unsigned long ibwrtfA(int ud, const char *filename) {
+	data(record("ibwrtfA", 1, (long)ud), filename, strlen(filename));
+	return finish("ibwrtfA", 0, 0);
+}
+
+unsigned long ibwrtfW(int ud, const wchar_t *filename) {
+	record("ibwrtfW", 1, (long)ud);
+	return finish("ibwrtfW", 0, 0);
+}
+
+unsigned long Ibsta(void) { return ibsta; }
+unsigned long Iberr(void) { return iberr; }
+unsigned long Ibcnt(void) { return ibcnt; }
+
+unsigned long ThreadIbsta(void) { return t_sta; }
+unsigned long ThreadIberr(void) { return t_err; }
+unsigned long ThreadIbcnt(void) { return t_cnt; }
+
+//
+// NI-488.2 functions
+//
+
+// outlist fills results with the outputs scripted for fn, limit at most,
+// and returns how many there were.
+static size_t outlist(const char *fn, short *results, size_t limit) {
+	fake_func *f = lookup(fn, 0);
+	size_t i, n = 0;
+	if (f != NULL)
+		n = (size_t)f->nout;
+	if (n > limit)
+		n = limit;
+	for (i = 0; i < n; i++)
+		results[i] = f->out[i];
+	return n;
+}
+
+void AllSpoll(int boardID, const short *addrlist, short *results) {
+	fake_call *c = record("AllSpoll", 1, (long)boardID);
+	list(c, addrlist);
+	finish("AllSpoll", 0, outlist("AllSpoll", results, c->nlist));
+}
+
+void DevClear(int boardID, short addr) {
+	record("DevClear", 2, (long)boardID, (long)addr);
+	finish("DevClear", 0, 0);
+}
+
+void DevClearList(int boardID, const short *addrlist) {
+	list(record("DevClearList", 1, (long)boardID), addrlist);
+	finish("DevClearList", 0, 0);
+}
+
+void EnableLocal(int boardID, const short *addrlist) {
+	list(record("EnableLocal", 1, (long)boardID), addrlist);
+	finish("EnableLocal", 0, 0);
+}
+
+void EnableRemote(int boardID, const short *addrlist) {
+	list(record("EnableRemote", 1, (long)boardID), addrlist);
+	finish("EnableRemote", 0, 0);
+}
+
+void FindLstn(int boardID, const short *addrlist, short *results, size_t limit) {
+	list(record("FindLstn", 2, (long)boardID, (long)limit), addrlist);
+	finish("FindLstn", 0, outlist("FindLstn", results, limit));
+}
+
+void FindRQS(int boardID, const short *addrlist, short *dev_stat) {
+	list(record("FindRQS", 1, (long)boardID), addrlist);
+	*dev_stat = out("FindRQS", 0, 0);
+	finish("FindRQS", 0, 0);
+}
+
+void PPoll(int boardID, short *result) {
+	record("PPoll", 1, (long)boardID);
+	*result = out("PPoll", 0, 0);
+	finish("PPoll", 0, 0);
+}
+
+void PPollConfig(int boardID, short addr, int dataLine, int lineSense) {
+	record("PPollConfig", 4, (long)boardID, (long)addr, (long)dataLine, (long)lineSense);
+	finish("PPollConfig", 0, 0);
+}
+
+void PPollUnconfig(int boardID, const short *addrlist) {
+	list(record("PPollUnconfig", 1, (long)boardID), addrlist);
+	finish("PPollUnconfig", 0, 0);
+}
+
+void PassControl(int boardID, short addr) {
+	record("PassControl", 2, (long)boardID, (long)addr);
+	finish("PassControl", 0, 0);
+}
+
+void RcvRespMsg(int boardID, void *buffer, size_t cnt, int Termination) {
+	int end;
+	size_t n;
+	record("RcvRespMsg", 3, (long)boardID, (long)cnt, (long)Termination);
+	n = readdata(buffer, cnt, &end);
+	finish("RcvRespMsg", end ? END : 0, n);
+}
+
+void ReadStatusByte(int boardID, short addr, short *result) {
+	record("ReadStatusByte", 2, (long)boardID, (long)addr);
+	*result = out("ReadStatusByte", 0, 0);
+	finish("ReadStatusByte", 0, 1);
+}
+
+void Receive(int boardID, short addr, void *buffer, size_t cnt, int Termination) {
+	int end;
+	size_t n;
+	record("Receive", 4, (long)boardID, (long)addr, (long)cnt, (long)Termination);
+	n = readdata(buffer, cnt, &end);
+	finish("Receive", end ? END : 0, n);
+}
+
+void ReceiveSetup(int boardID, short addr) {
+	record("ReceiveSetup", 2, (long)boardID, (long)addr);
+	finish("ReceiveSetup", 0, 0);
+}
+
+void ResetSys(int boardID, const short *addrlist) {
+	list(record("ResetSys", 1, (long)boardID), addrlist);
+	finish("ResetSys", 0, 0);
+}
+
+void Send(int boardID, short addr, const void *databuf, size_t datacnt, int eotMode) {
+	fake_call *c = record("Send", 4, (long)boardID, (long)addr, (long)datacnt, (long)eotMode);
+	data(c, databuf, datacnt);
+	finish("Send", 0, datacnt);
+}
+
+void SendCmds(int boardID, const void *buffer, size_t cnt) {
+	data(record("SendCmds", 2, (long)boardID, (long)cnt), buffer, cnt);
+	finish("SendCmds", 0, cnt);
+}
+
+void SendDataBytes(int boardID, const void *buffer, size_t cnt, int eot_mode) {
+	data(record("SendDataBytes", 3, (long)boardID, (long)cnt, (long)eot_mode), buffer, cnt);
+	finish("SendDataBytes", 0, cnt);
+}
+
+void SendIFC(int boardID) {
+	record("SendIFC", 1, (long)boardID);
+	finish("SendIFC", 0, 0);
+}
+
+void SendLLO(int boardID) {
+	record("SendLLO", 1, (long)boardID);
+	finish("SendLLO", 0, 0);
+}
+
+void SendList(int boardID, const short *addrlist, const void *databuf, size_t datacnt, int eotMode) {
+	fake_call *c = record("SendList", 3, (long)boardID, (long)datacnt, (long)eotMode);
+	list(c, addrlist);
+	data(c, databuf, datacnt);
+	finish("SendList", 0, datacnt);
+}
+
+void SendSetup(int boardID, const short *addrlist) {
+	list(record("SendSetup", 1, (long)boardID), addrlist);
+	finish("SendSetup", 0, 0);
+}
+
+void SetRWLS(int boardID, const short *addrlist) {
+	list(record("SetRWLS", 1, (long)boardID), addrlist);
+	finish("SetRWLS", 0, 0);
+}
+
+void TestSRQ(int boardID, short *result) {
+	record("TestSRQ", 1, (long)boardID);
+	*result = out("TestSRQ", 0, 0);
+	finish("TestSRQ", 0, 0);
+}
+
+void TestSys(int boardID, const short *addrlist, short *results) {
+	fake_call *c = record("TestSys", 1, (long)boardID);
+	size_t i, failed = 0;
+	list(c, addrlist);
+	outlist("TestSys", results, c->nlist);
+	for (i = 0; i < (size_t)c->nlist; i++)
+		failed += results[i] != 0;
+	finish("TestSys", 0, failed);
+}
+
+void Trigger(int boardID, short addr) {
+	record("Trigger", 2, (long)boardID, (long)addr);
+	finish("Trigger", 0, 0);
+}
+
+void TriggerList(int boardID, const short *addrlist) {
+	list(record("TriggerList", 1, (long)boardID), addrlist);
+	finish("TriggerList", 0, 0);
+}
+
+void WaitSRQ(int boardID, short *result) {
+	record("WaitSRQ", 1, (long)boardID);
+	*result = out("WaitSRQ", 0, 0);
+	finish("WaitSRQ", 0, 0);
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"bufio"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// proto matches a function prototype, the calling convention is NI488CC in
+// ni4882.h and __stdcall in ni488.h.
+var proto = regexp.MustCompile(`\b(?:NI488CC|__stdcall)\s+(\w+)\s*\(`)
+
+// TestHeadersWrapped fails if a function declared in ni4882.h or ni488.h
+// has no exported Go wrapper in the package. The Go name of a C function
+// is its name with the first letter upper cased, e.g. ibrd is Ibrd. The A
+// (ANSI) and W (wide) variants of the routines taking names share a
+// wrapper, so ibfindA and ibfindW are both Ibfind.
+func TestHeadersWrapped(t *testing.T) {
+	wrappers, err := goFuncs(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, h := range []string{"ni4882.h", "ni488.h"} {
+		funcs, err := cFuncs(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(funcs) == 0 {
+			t.Errorf("%s: no functions found", h)
+		}
+		for _, f := range funcs {
+			if name := goName(f, funcs); !wrappers[name] {
+				t.Errorf("%s: %s has no wrapper %s", h, f, name)
+			}
+		}
+	}
+}
+
+// cFuncs returns the names of the functions declared in the header file.
+func cFuncs(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	seen := make(map[string]bool)
+	var funcs []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "typedef") {
+			continue
+		}
+		if m := proto.FindStringSubmatch(line); m != nil && !seen[m[1]] {
+			seen[m[1]] = true
+			funcs = append(funcs, m[1])
+		}
+	}
+	return funcs, s.Err()
+}
+
+// goName returns the name of the Go wrapper of the C function f declared
+// alongside funcs.
+func goName(f string, funcs []string) string {
+	if base := strings.TrimRight(f, "AW"); base != f && len(f)-len(base) == 1 {
+		other := base + "A"
+		if strings.HasSuffix(f, "A") {
+			other = base + "W"
+		}
+		for _, g := range funcs {
+			if g == other {
+				f = base
+				break
+			}
+		}
+	}
+	return strings.ToUpper(f[:1]) + f[1:]
+}
+
+// goFuncs returns the exported functions of the package in dir.
+func goFuncs(dir string) (map[string]bool, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	funcs := make(map[string]bool)
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		if f.Name.Name == "main" {
+			continue
+		}
+		for _, d := range f.Decls {
+			if fd, ok := d.(*ast.FuncDecl); ok && fd.Recv == nil && fd.Name.IsExported() {
+				funcs[fd.Name.Name] = true
+			}
+		}
+	}
+	return funcs, nil
+}
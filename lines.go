@@ -0,0 +1,136 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// LineState is the state of a GPIB control line as reported by Iblines.
+type LineState int8
+
+const (
+	LineUnknown    LineState = iota // the board cannot monitor the line
+	LineUnasserted                  // the line is monitored and unasserted
+	LineAsserted                    // the line is monitored and asserted
+)
+
+// String returns "?", "0" or "1".
+func (s LineState) String() string {
+	switch s {
+	case LineUnasserted:
+		return "0"
+	case LineAsserted:
+		return "1"
+	}
+	return "?"
+}
+
+// BusLines is the state of the eight GPIB control lines, decoded from the
+// ValidXXX and BusXXX bits of Iblines.
+type BusLines struct {
+	EOI, ATN, SRQ, REN, IFC, NRFD, NDAC, DAV LineState
+}
+
+var busLines = [...]struct {
+	valid, bus int16
+	name       string
+	line       func(l *BusLines) *LineState
+}{
+	{ValidEOI, BusEOI, "EOI", func(l *BusLines) *LineState { return &l.EOI }},
+	{ValidATN, BusATN, "ATN", func(l *BusLines) *LineState { return &l.ATN }},
+	{ValidSRQ, BusSRQ, "SRQ", func(l *BusLines) *LineState { return &l.SRQ }},
+	{ValidREN, BusREN, "REN", func(l *BusLines) *LineState { return &l.REN }},
+	{ValidIFC, BusIFC, "IFC", func(l *BusLines) *LineState { return &l.IFC }},
+	{ValidNRFD, BusNRFD, "NRFD", func(l *BusLines) *LineState { return &l.NRFD }},
+	{ValidNDAC, BusNDAC, "NDAC", func(l *BusLines) *LineState { return &l.NDAC }},
+	{ValidDAV, BusDAV, "DAV", func(l *BusLines) *LineState { return &l.DAV }},
+}
+
+// DecodeLines decodes the value returned by Iblines.
+func DecodeLines(v uint16) BusLines {
+	var l BusLines
+	for _, b := range busLines {
+		s := LineUnknown
+		switch {
+		case int16(v)&b.valid == 0:
+		case int16(v)&b.bus != 0:
+			s = LineAsserted
+		default:
+			s = LineUnasserted
+		}
+		*b.line(&l) = s
+	}
+	return l
+}
+
+// String returns the state of each line, e.g.
+// "EOI=0 ATN=1 SRQ=0 REN=1 IFC=0 NRFD=1 NDAC=0 DAV=?".
+func (l BusLines) String() string {
+	s := make([]string, len(busLines))
+	for i, b := range busLines {
+		s[i] = b.name + "=" + b.line(&l).String()
+	}
+	return strings.Join(s, " ")
+}
+
+// Lines returns the state of the GPIB control lines of the board ud.
+func Lines(ud int) (BusLines, error) {
+	v, _, err := Iblines(ud)
+	if err != nil {
+		return BusLines{}, err
+	}
+	return DecodeLines(v), nil
+}
+
+// LineSample is a change of the control lines seen by WatchLines.
+type LineSample struct {
+	Time  time.Time
+	Lines BusLines
+	Err   error // set if Iblines failed, ending the watch
+}
+
+// WatchLines polls Iblines on the board ud every interval and sends the
+// lines on the returned channel whenever they change, starting with their
+// state now, e.g. to find the handshake line a hung transfer is stuck on.
+// Changes shorter than interval are missed. The channel is closed when ctx
+// is done, or after a sample holding the error if Iblines fails.
+func WatchLines(ctx context.Context, ud int, interval time.Duration) (<-chan LineSample, error) {
+	l, err := Lines(ud)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan LineSample, 16)
+	ch <- LineSample{Time: time.Now(), Lines: l}
+	go func() {
+		defer close(ch)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		last := l
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+			l, err := Lines(ud)
+			if err == nil && l == last {
+				continue
+			}
+			last = l
+			select {
+			case ch <- LineSample{Time: time.Now(), Lines: l, Err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
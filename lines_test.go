@@ -0,0 +1,69 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDecodeLines(t *testing.T) {
+	const (
+		u = LineUnknown
+		n = LineUnasserted
+		a = LineAsserted
+	)
+	tests := []struct {
+		v    uint16
+		want BusLines
+		s    string
+	}{
+		{0, BusLines{u, u, u, u, u, u, u, u}, "EOI=? ATN=? SRQ=? REN=? IFC=? NRFD=? NDAC=? DAV=?"},
+		{0xFF00, BusLines{u, u, u, u, u, u, u, u}, "EOI=? ATN=? SRQ=? REN=? IFC=? NRFD=? NDAC=? DAV=?"},
+		{0x00FF, BusLines{n, n, n, n, n, n, n, n}, "EOI=0 ATN=0 SRQ=0 REN=0 IFC=0 NRFD=0 NDAC=0 DAV=0"},
+		{0xFFFF, BusLines{a, a, a, a, a, a, a, a}, "EOI=1 ATN=1 SRQ=1 REN=1 IFC=1 NRFD=1 NDAC=1 DAV=1"},
+		{0x8000 | ValidEOI | ValidATN | ValidSRQ | ValidREN | BusREN,
+			BusLines{EOI: a, ATN: n, SRQ: n, REN: a}, "EOI=1 ATN=0 SRQ=0 REN=1 IFC=? NRFD=? NDAC=? DAV=?"},
+		{ValidDAV | BusDAV | ValidNRFD | BusNDAC,
+			BusLines{NRFD: n, DAV: a}, "EOI=? ATN=? SRQ=? REN=? IFC=? NRFD=0 NDAC=? DAV=1"},
+	}
+	for _, tt := range tests {
+		l := DecodeLines(tt.v)
+		if l != tt.want || l.String() != tt.s {
+			t.Errorf("DecodeLines(%#04x) = %v, want %v", tt.v, l, tt.s)
+		}
+	}
+}
+
+func TestWatchLines(t *testing.T) {
+	useSim(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := WatchLines(ctx, 0, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := <-ch; first.Err != nil || first.Lines.REN != LineUnasserted {
+		t.Errorf("first sample %+v, want REN unasserted", first)
+	}
+	Ibsre(0, 1)
+	if next := <-ch; next.Err != nil || next.Lines.REN != LineAsserted {
+		t.Errorf("sample %+v, want REN asserted", next)
+	}
+	// Nothing changes, nothing is sent.
+	select {
+	case l := <-ch:
+		t.Errorf("sample %+v with no change", l)
+	case <-time.After(20 * time.Millisecond):
+	}
+	cancel()
+	for range ch {
+	}
+
+	if _, err := WatchLines(context.Background(), 99, time.Millisecond); err == nil {
+		t.Error("WatchLines of an invalid descriptor did not fail")
+	}
+}
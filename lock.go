@@ -0,0 +1,134 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// The interface lock taken with iblck is held by the process, so it keeps
+// other processes off the interface but not the goroutines of this one.
+// Board and Device can also take an in-process lock, see SetLocalLock,
+// which the goroutines calling Lock on the same interface queue on. When
+// the library has no interface lock, iblck failing with ECAP, the
+// in-process lock is taken alone.
+
+// lockPoll is the longest iblck call made by lock, so that ctx is checked
+// in between.
+const lockPoll = 100 * time.Millisecond
+
+// localLocks holds the in-process lock of each interface, by board index.
+// A lock is held by sending to its channel.
+var localLocks = struct {
+	sync.Mutex
+	m map[int]chan struct{}
+}{m: make(map[int]chan struct{})}
+
+func localLock(board int) chan struct{} {
+	localLocks.Lock()
+	defer localLocks.Unlock()
+	l := localLocks.m[board]
+	if l == nil {
+		l = make(chan struct{}, 1)
+		localLocks.m[board] = l
+	}
+	return l
+}
+
+// lock acquires the interface lock for ud, and first the in-process lock
+// of board if local is set, waiting up to wait for them. It fails with an
+// *Error holding ELCK, which also matches ctx.Err() if ctx is done first.
+func lock(ctx context.Context, ud, board int, local bool, wait time.Duration) error {
+	deadline := time.Now().Add(wait)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(deadline) {
+		deadline = dl
+	}
+	if local {
+		if err := lockLocal(ctx, ud, board, deadline); err != nil {
+			return err
+		}
+	}
+	err := lockDriver(ctx, ud, deadline)
+	switch {
+	case err == nil:
+	case local && errors.Is(err, ErrCapability):
+		err = nil
+	case local:
+		<-localLock(board)
+	}
+	return err
+}
+
+func lockLocal(ctx context.Context, ud, board int, deadline time.Time) error {
+	l := localLock(board)
+	select {
+	case l <- struct{}{}:
+		return nil
+	default:
+	}
+	t := time.NewTimer(time.Until(deadline))
+	defer t.Stop()
+	select {
+	case l <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return locked(ud, Result{}, ctx.Err())
+	case <-t.C:
+		return locked(ud, Result{}, nil)
+	}
+}
+
+func lockDriver(ctx context.Context, ud int, deadline time.Time) error {
+	for {
+		wait := time.Until(deadline)
+		if wait < 0 {
+			wait = 0
+		} else if wait > lockPoll {
+			wait = lockPoll
+		}
+		r, err := Iblck(ud, 1, uint(wait/time.Millisecond))
+		if err == nil || !errors.Is(err, ErrLocked) {
+			return err
+		}
+		if ctx.Err() != nil {
+			return locked(ud, r, ctx.Err())
+		}
+		if !time.Now().Before(deadline) {
+			return err
+		}
+	}
+}
+
+// unlock releases the interface lock for ud and the in-process lock of
+// board if local is set.
+func unlock(ud, board int, local bool) error {
+	_, err := Iblck(ud, 0, 0)
+	if local {
+		if errors.Is(err, ErrCapability) {
+			err = nil
+		}
+		select {
+		case <-localLock(board):
+		default:
+		}
+	}
+	return err
+}
+
+// locked returns the ELCK Error of locking ud, given up because of cause,
+// or because the wait ran out if cause is nil.
+func locked(ud int, r Result, cause error) error {
+	return &Error{
+		Op:    "iblck",
+		Ud:    ud,
+		Sta:   r.Ibsta | ERR,
+		Code:  ELCK,
+		Count: r.Ibcntl,
+		Err:   cause,
+	}
+}
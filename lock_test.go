@@ -0,0 +1,124 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLockHeld(t *testing.T) {
+	tests := []struct {
+		name string
+		wait time.Duration
+		ctx  func() (context.Context, context.CancelFunc)
+		took time.Duration // how long Lock must wait at least
+		err  error         // the error matched besides ErrLocked
+	}{
+		{"wait", 150 * time.Millisecond,
+			func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+			150 * time.Millisecond, nil},
+		{"no wait", 0,
+			func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+			0, nil},
+		{"deadline", time.Hour,
+			func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 50*time.Millisecond)
+			},
+			50 * time.Millisecond, nil},
+		{"cancel", time.Hour,
+			func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(30*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			30 * time.Millisecond, context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := useSim(t)
+			s.HoldLock(0, true)
+			b := NewBoard(0)
+			start := time.Now()
+			ctx, cancel := tt.ctx()
+			defer cancel()
+			err := b.Lock(ctx, tt.wait)
+			d := time.Since(start)
+			if !errors.Is(err, ErrLocked) || tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("Lock = %v, want ELCK matching %v", err, tt.err)
+			}
+			// Lock polls iblck, ctx is seen within lockPoll.
+			if d < tt.took || d > tt.took+lockPoll+100*time.Millisecond {
+				t.Errorf("Lock returned after %v, want %v", d, tt.took)
+			}
+		})
+	}
+}
+
+func TestLockReleased(t *testing.T) {
+	s := useSim(t)
+	b := NewBoard(0)
+	if err := b.Lock(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	s.HoldLock(0, true)
+	time.AfterFunc(30*time.Millisecond, func() { s.HoldLock(0, false) })
+	if err := b.Lock(context.Background(), time.Second); err != nil {
+		t.Errorf("Lock after the other process released it: %v", err)
+	}
+	b.Unlock()
+}
+
+func TestLocalLock(t *testing.T) {
+	s := useSim(t)
+	_, ud := simDev(t, s, T1s, 0)
+	d := NewDevice(ud)
+	d.SetLocalLock(true)
+	b := NewBoard(0)
+	b.SetLocalLock(true)
+
+	if err := d.Lock(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Lock(context.Background(), 20*time.Millisecond); !errors.Is(err, ErrLocked) {
+		t.Errorf("Lock with the in-process lock held = %v, want ELCK", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if err := b.Lock(ctx, time.Hour); !errors.Is(err, ErrLocked) || !errors.Is(err, context.Canceled) {
+		t.Errorf("Lock canceled = %v, want ELCK and context.Canceled", err)
+	}
+
+	// The board takes its turn once the device unlocks.
+	unlocked := make(chan time.Time, 1)
+	time.AfterFunc(30*time.Millisecond, func() {
+		unlocked <- time.Now()
+		d.Unlock()
+	})
+	if err := b.Lock(context.Background(), time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if got := time.Now(); got.Before(<-unlocked) {
+		t.Error("Lock returned before the device unlocked")
+	}
+	b.Unlock()
+
+	// The interface lock of another process is waited for after the
+	// in-process lock, which is released again on failure.
+	s.HoldLock(0, true)
+	if err := b.Lock(context.Background(), 20*time.Millisecond); !errors.Is(err, ErrLocked) {
+		t.Errorf("Lock held by another process = %v, want ELCK", err)
+	}
+	s.HoldLock(0, false)
+	if err := d.Lock(context.Background(), 0); err != nil {
+		t.Errorf("in-process lock left held: %v", err)
+	}
+	d.Unlock()
+}
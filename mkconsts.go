@@ -0,0 +1,415 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+//go:build ignore
+// +build ignore
+
+// Mkconsts generates zconsts.go, the constants of ni4882.h and ni488.h as
+// typed Go constants, so the package needs no cgo to get at them. Run it
+// with go generate from the package directory.
+//
+// Every object-like macro with an integer value is turned into a constant;
+// ni488.h only adds the ones missing from ni4882.h, and a macro defined
+// with different values in the two headers is an error. Command bytes,
+// status bits, error codes, timeout codes and configuration options get
+// their own types, along with the tables their String methods use.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var headers = []string{"ni4882.h", "ni488.h"}
+
+const output = "zconsts.go"
+
+// A group is a set of constants of the same type.
+type group struct {
+	typ      string         // the Go type, "" for untyped
+	doc      string         // the doc comment of the const block
+	match    *regexp.Regexp // the macro names in the group
+	names    string         // the name table for String, "" for none
+	fallback string         // how String shows values without a name
+}
+
+var groups = []*group{
+	{
+		typ:      "Command",
+		doc:      "Command bytes, the GPIB interface messages sent with Ibcmd and SendCmds.",
+		match:    regexp.MustCompile(`^(UNL|UNT|GTL|SDC|PPC|GET|TCT|LLO|DCL|PPU|SPE|SPD|PPE|PPD)$`),
+		names:    "commandNames",
+		fallback: "Command",
+	},
+	{
+		typ:   "Status",
+		doc:   "Status bits of ibsta, also the Ibwait and Ibnotify event mask.",
+		match: regexp.MustCompile(`^(ERR|TIMO|END|SRQI|RQS|CMPL|LOK|REM|CIC|ATN|TACS|LACS|DTAS|DCAS)$`),
+	},
+	{
+		typ:      "ErrorCode",
+		doc:      "Error codes of iberr, meaningful only when ERR is set in ibsta.",
+		match:    regexp.MustCompile(`^(E[A-Z]{3}|WCFG)$`),
+		names:    "errorCodeNames",
+		fallback: "iberr",
+	},
+	{
+		typ:      "Timeout",
+		doc:      "Timeout codes of Ibtmo and Ibdev, the times are ideal ones.",
+		match:    regexp.MustCompile(`^T(NONE|[0-9]+(us|ms|s))$`),
+		names:    "timeoutNames",
+		fallback: "Timeout",
+	},
+	{
+		typ:      "ConfigOption",
+		doc:      "Configuration options of Ibconfig (Ibc) and Ibask (Iba).",
+		match:    regexp.MustCompile(`^Ib[ca]`),
+		names:    "configOptionNames",
+		fallback: "ConfigOption",
+	},
+	{
+		doc:   "EOS mode bits, IBLN secondary addresses, Send and Receive modes,\n// iblines bits and the iblockx wait times.",
+		match: regexp.MustCompile(``),
+	},
+}
+
+// A macro is an object-like #define with an integer value.
+type macro struct {
+	name    string
+	expr    string // the value as a Go expression
+	value   int64
+	comment string
+	header  string
+}
+
+var (
+	defineRE  = regexp.MustCompile(`^#\s*define\s+(\w+)\s+(.*)$`)
+	commentRE = regexp.MustCompile(`\s*(//(.*)|/\*(.*?)(\*/|$))`)
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("mkconsts: ")
+	var all []*macro
+	byName := make(map[string]*macro)
+	for _, h := range headers {
+		ms, err := parse(h, byName)
+		if err != nil {
+			log.Fatal(err)
+		}
+		prev := -1 // where the last macro of h is in all
+		for _, m := range ms {
+			if old := byName[m.name]; old != nil {
+				if old.value != m.value {
+					log.Fatalf("%s is %d in %s and %d in %s", m.name, old.value, old.header, m.value, m.header)
+				}
+				prev = indexOf(all, old)
+				continue
+			}
+			byName[m.name] = m
+			all = append(all[:prev+1], append([]*macro{m}, all[prev+1:]...)...)
+			prev++
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by mkconsts.go from %s; DO NOT EDIT.\n\n", strings.Join(headers, " and "))
+	fmt.Fprintf(&buf, "package ni488\n\nimport \"strconv\"\n")
+	grouped := make(map[*group][]*macro)
+	for _, m := range all {
+		for _, g := range groups {
+			if g.match.MatchString(m.name) {
+				grouped[g] = append(grouped[g], m)
+				break
+			}
+		}
+	}
+	for _, g := range groups {
+		writeGroup(&buf, g, grouped[g])
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(output, src, 0666); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func indexOf(ms []*macro, m *macro) int {
+	for i := range ms {
+		if ms[i] == m {
+			return i
+		}
+	}
+	return -1
+}
+
+func writeGroup(buf *bytes.Buffer, g *group, ms []*macro) {
+	fmt.Fprintf(buf, "\n// %s\nconst (\n", g.doc)
+	for _, m := range ms {
+		typ := ""
+		if g.typ != "" {
+			typ = " " + g.typ
+		}
+		fmt.Fprintf(buf, "\t%s%s = %s", m.name, typ, m.expr)
+		if m.comment != "" {
+			fmt.Fprintf(buf, " // %s", m.comment)
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString(")\n")
+
+	switch {
+	case g.typ == "Status":
+		buf.WriteString("\n// statusBits names the status bits, most significant first.\n")
+		buf.WriteString("var statusBits = []struct {\n\tbit  Status\n\tname string\n}{\n")
+		for _, m := range ms {
+			fmt.Fprintf(buf, "\t{%s, %q},\n", m.name, m.name)
+		}
+		buf.WriteString("}\n")
+	case g.names != "":
+		seen := make(map[int64]bool)
+		fmt.Fprintf(buf, "\nvar %s = map[%s]string{\n", g.names, g.typ)
+		for _, m := range ms {
+			if !seen[m.value] {
+				seen[m.value] = true
+				fmt.Fprintf(buf, "\t%s: %q,\n", m.name, m.name)
+			}
+		}
+		buf.WriteString("}\n")
+		fmt.Fprintf(buf, "\n// String returns the name of the constant with the value of v.\n")
+		fmt.Fprintf(buf, "func (v %s) String() string {\n", g.typ)
+		fmt.Fprintf(buf, "\tif s, ok := %s[v]; ok {\n\t\treturn s\n\t}\n", g.names)
+		fmt.Fprintf(buf, "\treturn %q + strconv.Itoa(int(v)) + \")\"\n}\n", g.fallback+"(")
+	}
+}
+
+// parse returns the macros defined in the header file, resolving the names
+// they use from the header itself or known.
+func parse(file string, known map[string]*macro) ([]*macro, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	local := make(map[string]*macro)
+	var ms []*macro
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		m := defineRE.FindStringSubmatch(strings.TrimSpace(s.Text()))
+		if m == nil {
+			continue
+		}
+		name, rest := m[1], m[2]
+		comment := ""
+		if c := commentRE.FindStringSubmatchIndex(rest); c != nil {
+			comment = strings.TrimSpace(rest[c[2]:c[3]])
+			comment = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(comment, "//"), "/*"))
+			comment = strings.TrimSpace(strings.TrimSuffix(comment, "*/"))
+			comment = strings.TrimSuffix(comment, ".")
+			rest = rest[:c[0]]
+		}
+		e := &evaluator{toks: tokenize(rest), lookup: func(id string) (*macro, bool) {
+			if m, ok := local[id]; ok {
+				return m, true
+			}
+			m, ok := known[id]
+			return m, ok
+		}}
+		v, expr, ok := e.eval()
+		if !ok {
+			continue // not an integer, e.g. a function alias
+		}
+		mac := &macro{name: name, expr: expr, value: v, comment: comment, header: file}
+		local[name] = mac
+		ms = append(ms, mac)
+	}
+	return ms, s.Err()
+}
+
+var tokenRE = regexp.MustCompile(`\s*(0[xX][0-9a-fA-F]+|[0-9]+|\w+|<<|>>|.)`)
+
+func tokenize(s string) []string {
+	var toks []string
+	for _, m := range tokenRE.FindAllStringSubmatch(strings.TrimSpace(s), -1) {
+		if t := strings.TrimSpace(m[1]); t != "" {
+			toks = append(toks, t)
+		}
+	}
+	return toks
+}
+
+// evaluator evaluates the constant expressions of the headers: integers,
+// names, parentheses, casts to short and unsigned short, unary minus and
+// complement, shifts, and bitwise and and or.
+type evaluator struct {
+	toks   []string
+	pos    int
+	lookup func(name string) (*macro, bool)
+	cast   bool // a cast was applied, the Go expression is the value
+	err    bool
+}
+
+// eval returns the value of the expression and a Go expression for it.
+func (e *evaluator) eval() (v int64, expr string, ok bool) {
+	if len(e.toks) == 0 {
+		return 0, "", false
+	}
+	v = e.or()
+	if e.err || e.pos != len(e.toks) {
+		return 0, "", false
+	}
+	switch {
+	case e.cast && v < 0:
+		expr = strconv.FormatInt(v, 10)
+	case e.cast:
+		expr = fmt.Sprintf("0x%04X", v)
+	default:
+		toks := e.toks
+		for len(toks) > 2 && toks[0] == "(" && closing(toks) == len(toks)-1 {
+			toks = toks[1 : len(toks)-1]
+		}
+		expr = strings.Join(toks, "")
+		expr = strings.NewReplacer("<<", " << ", ">>", " >> ", "|", " | ", "&", " & ").Replace(expr)
+	}
+	return v, expr, true
+}
+
+// closing returns the index of the parenthesis closing toks[0].
+func closing(toks []string) int {
+	depth := 0
+	for i, t := range toks {
+		switch t {
+		case "(":
+			depth++
+		case ")":
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func (e *evaluator) peek() string {
+	if e.pos < len(e.toks) {
+		return e.toks[e.pos]
+	}
+	return ""
+}
+
+func (e *evaluator) next() string {
+	t := e.peek()
+	e.pos++
+	return t
+}
+
+func (e *evaluator) or() int64 {
+	v := e.and()
+	for e.peek() == "|" {
+		e.next()
+		v |= e.and()
+	}
+	return v
+}
+
+func (e *evaluator) and() int64 {
+	v := e.shift()
+	for e.peek() == "&" {
+		e.next()
+		v &= e.shift()
+	}
+	return v
+}
+
+func (e *evaluator) shift() int64 {
+	v := e.unary()
+	for {
+		switch e.peek() {
+		case "<<":
+			e.next()
+			v <<= uint(e.unary())
+		case ">>":
+			e.next()
+			v >>= uint(e.unary())
+		default:
+			return v
+		}
+	}
+}
+
+func (e *evaluator) unary() int64 {
+	switch e.peek() {
+	case "-":
+		e.next()
+		return -e.unary()
+	case "~":
+		e.next()
+		return ^e.unary()
+	case "(":
+		if conv := e.castType(); conv != nil {
+			e.cast = true
+			return conv(e.unary())
+		}
+	}
+	return e.primary()
+}
+
+// castType consumes a cast to a type and returns the conversion to it, or
+// returns nil if the parenthesis does not start a cast.
+func (e *evaluator) castType() func(int64) int64 {
+	var words []string
+	i := e.pos + 1
+	for ; i < len(e.toks) && e.toks[i] != ")"; i++ {
+		words = append(words, e.toks[i])
+	}
+	if i == len(e.toks) {
+		return nil
+	}
+	var conv func(int64) int64
+	switch strings.Join(words, " ") {
+	case "short":
+		conv = func(v int64) int64 { return int64(int16(v)) }
+	case "unsigned short":
+		conv = func(v int64) int64 { return int64(uint16(v)) }
+	default:
+		return nil
+	}
+	e.pos = i + 1
+	return conv
+}
+
+func (e *evaluator) primary() int64 {
+	t := e.next()
+	switch {
+	case t == "(":
+		v := e.or()
+		if e.next() != ")" {
+			e.err = true
+		}
+		return v
+	case t != "" && t[0] >= '0' && t[0] <= '9':
+		v, err := strconv.ParseInt(t, 0, 64)
+		if err != nil {
+			// C reads 0x014 as hex and 014 as octal, as does ParseInt.
+			e.err = true
+		}
+		return v
+	default:
+		m, ok := e.lookup(t)
+		if !ok {
+			e.err = true
+			return 0
+		}
+		return m.value
+	}
+}
@@ -22,39 +22,46 @@
 //
 // Direct download: http://download.ni.com/support/softlib/gpib/
 //
+// Every call goes through a Driver. Builds with cgo enabled use the NI-488.2
+// C library; other backends can be installed with SetDriver, and building
+// with the nogpib tag leaves the C library out entirely.
+//
 package ni488
 
+//go:generate go run mkconsts.go
+
+// The constants of ni4882.h and ni488.h are generated into zconsts.go, so
+// they need no cgo; run go generate after updating the headers.
+
+// Command is a GPIB command byte (interface message), UNL to PPD, as sent
+// with Ibcmd and SendCmds.
+type Command byte
+
+// ConfigOption is an Ibconfig (Ibc) or Ibask (Iba) configuration option.
+type ConfigOption int
+
+// ValidATNV is the iblines valid bit for ATN.
+//
+// Deprecated: use ValidATN.
+const ValidATNV = ValidATN
+
 // TODO:
 // -
 
-/*
-#cgo linux LDFLAGS: -lgpibapi
-#cgo darwin CFLAGS: -I.
-#cgo darwin LDFLAGS: -framework NI488
-#cgo windows CFLAGS: -I.
-#cgo windows LDFLAGS: -lgpib-32 -LC:/WINDOWS/system32
-#include <stdlib.h>
-#if defined(__amd64) || defined(__amd64__) || defined(__x86_64) || defined(__x86_64__) && !defined(__APPLE__)
-#define size_g size_t
-#include <ni4882.h>
-#else
-#define size_g long
-#include <ni488.h>
-#endif
-*/
-import "C"
-import "unsafe"
-
 var PackageVersion string = "v0.4"
 
 // GetPad extracts and returns the primary instrument
 // address from a base instrument address.
+//
+// Deprecated: use Address.Primary.
 func GetPad(addr uint16) int {
 	return int(addr & 0xFF)
 }
 
 // GetSad extracts and returns the secondary instrument
 // address from a base instrument address.
+//
+// Deprecated: use Address.Secondary.
 func GetSad(addr uint16) int {
 	return int(((addr) >> 8) & 0xFF)
 }
@@ -70,14 +77,43 @@ func GetSad(addr uint16) int {
 // for each thread. ThreadIbcntl returns the value of the thread-specific ibcntl
 // variable.
 
+// Goroutines move between threads, so these are only reliable between calls
+// made with the goroutine locked to its thread (runtime.LockOSThread).
+// Every function in the package also returns the Result of its call, which
+// is captured together with the call and is always reliable.
+
+// Ibsta returns the process-wide ibsta value, the status of the most recent
+// GPIB function call made by any thread.
+func Ibsta() Status {
+	return Status(driver().Ibsta())
+}
+
+// Iberr returns the process-wide iberr value, the error code of the most
+// recent GPIB function call made by any thread.
+func Iberr() ErrorCode {
+	return ErrorCode(driver().Iberr())
+}
+
+// Ibcnt returns the process-wide ibcnt value, the count of the most recent
+// GPIB function call made by any thread.
+func Ibcnt() uint32 {
+	return driver().Ibcntl()
+}
+
+// Ibcntl returns the process-wide ibcntl value, the count of the most
+// recent GPIB function call made by any thread.
+func Ibcntl() uint32 {
+	return driver().Ibcntl()
+}
+
 // ThreadIbsta returns the thread-specific ibsta value for the current thread.
 //
 // The return value is the value for the current thread of execution. The
 // value describes the state of the GPIB and the result of the most recent
 // GPIB function call in the thread.  Call ThreadIberr for a specific error
 // code.
-func ThreadIbsta() uint32 {
-	return uint32(C.ThreadIbsta())
+func ThreadIbsta() Status {
+	return Status(driver().ThreadIbsta())
 }
 
 // ThreadIberr returns the thread-specific iberr value for the current thread.
@@ -85,8 +121,8 @@ func ThreadIbsta() uint32 {
 // The return value is the most recent GPIB error code for the current
 // thread of execution. The value is meaningful only when ThreadIbsta returns
 // a value with the ERR bit set.
-func ThreadIberr() uint32 {
-	return uint32(C.ThreadIberr())
+func ThreadIberr() ErrorCode {
+	return ErrorCode(driver().ThreadIberr())
 }
 
 // ThreadIbcnt returns the thread-specific ibcnt value for the current thread.
@@ -95,7 +131,7 @@ func ThreadIberr() uint32 {
 // the most recent GPIB read, write, or command operation for the current
 // thread of execution or an error code if an error occured.
 func ThreadIbcnt() uint32 {
-	return uint32(C.ThreadIbcnt())
+	return driver().ThreadIbcntl()
 }
 
 // ThreadIbcntl returns the thread-specific ibcntl value for the current thread.
@@ -104,7 +140,7 @@ func ThreadIbcnt() uint32 {
 // the most recent GPIB read, write, or command operation for the current
 // thread of execution or an error code if an error occured.
 func ThreadIbcntl() uint32 {
-	return uint32(C.ThreadIbcnt())
+	return driver().ThreadIbcntl()
 }
 
 //  NI-488 Functions
@@ -114,21 +150,41 @@ func ThreadIbcntl() uint32 {
 // If ud is a device descriptor, ibrdf addresses the GPIB, reads data from
 // a GPIB device, and places the data into the file specified by filename.
 // If ud is a board descriptor, ibrdf reads data from a GPIB device and
-// places the data into the file specified by filename
-func Ibrdf(ud int, filename string) (ibsta uint32) {
-	n := C.CString(filename)
-	defer C.free(unsafe.Pointer(n))
-	return uint32(C.ibrdfA(C.int(ud), n))
-
+// places the data into the file specified by filename. See ReadTo for
+// reading into any io.Writer.
+func Ibrdf(ud int, filename string) (r Result, err error) {
+	return check("ibrdf", ud, driver().Ibrdf(ud, filename))
 }
 
 // Ibask returns the current value of various configuration parameters for the
 // specified board or device.
 //
 // The current value of the selected configuration item is returned in v.
-func Ibask(ud, option int) (v, ibsta uint32) {
-	return uint32(C.ibask(C.int(ud), C.int(option),
-		(*C.int)(unsafe.Pointer(&v))))
+func Ibask(ud int, option ConfigOption) (v int, r Result, err error) {
+	v, r = driver().Ibask(ud, option)
+	r, err = check("ibask", ud, r)
+	return
+}
+
+// Ibbna changes the access board of a device.
+//
+// Assigns the device described by ud to the board named udname, e.g.
+// "GPIB1". On success iberr holds the index of the previous access board.
+//
+// Obsolete, only in ni488.h: fails with ECAP when built against ni4882.h.
+func Ibbna(ud int, udname string) (r Result, err error) {
+	return check("ibbna", ud, driver().Ibbna(ud, udname))
+}
+
+// Ibbn assigns the device described by ud to the access board described by
+// bname.
+//
+// All subsequent bus activity with device ud occurs through the access board
+// bname. If the call succeeds iberr contains the previous access board index.
+//
+// Deprecated: use Ibbna.
+func Ibbn(ud int, udname string) (ibsta int) {
+	return int(driver().Ibbna(ud, udname).Ibsta)
 }
 
 // Ibcac uses the designated GPIB board to attempt to become the Active
@@ -138,25 +194,29 @@ func Ibask(ud, option int) (v, ibsta uint32) {
 // is non-zero the GPIB board takes control synchronously. Before calling
 // ibcac, the GPIB board must already be CIC. To make the board CIC, use
 // the ibsic function.
-func Ibcac(ud, v int) (ibsta uint32) {
-	ibsta = uint32(C.ibcac(C.int(ud), C.int(v)))
-	return
+func Ibcac(ud, v int) (r Result, err error) {
+	return check("ibcac", ud, driver().Ibcac(ud, v))
 }
 
 // Ibclr sends the GPIB Selected Device Clear (SDC) message to the device
 // described by ud.
-func Ibclr(ud int) (ibsta uint32) {
-	return uint32(C.ibclr(C.int(ud)))
+func Ibclr(ud int) (r Result, err error) {
+	return check("ibclr", ud, driver().Ibclr(ud))
 }
 
 // Ibcmd sends GPIB commands.
 //
 // Sends cmds over the GPIB as command bytes (interface messages). The actual
 // transferred byte count is returned in the global variable ibcntl.
-func Ibcmd(ud int, cmds string) (ibsta uint32) {
-	n := C.CString(cmds)
-	defer C.free(unsafe.Pointer(n))
-	return uint32(C.ibcmd(C.int(ud), unsafe.Pointer(n), C.size_g(len(cmds))))
+func Ibcmd(ud int, cmds string) (r Result, err error) {
+	return check("ibcmd", ud, driver().Ibcmd(ud, []byte(cmds)))
+}
+
+// IbcmdBuf is Ibcmd taking the command bytes as a []byte, which is passed
+// to the driver without a copy. It returns the number of bytes sent.
+func IbcmdBuf(ud int, cmds []byte) (n int, r Result, err error) {
+	r, err = check("ibcmd", ud, driver().Ibcmd(ud, cmds))
+	return r.count(len(cmds)), r, err
 }
 
 // Ibcmda sends GPIB commands asynchronously.
@@ -164,39 +224,55 @@ func Ibcmd(ud int, cmds string) (ibsta uint32) {
 // Sends cmds asynchronously over the GPIB as command bytes (interface
 // messages). The actual transferred byte count is returned in the global
 // variable ibcntl.
-func Ibcmda(ud int, cmds string) (ibsta uint32) {
-	n := C.CString(cmds)
-	defer C.free(unsafe.Pointer(n))
-	ibsta = uint32(C.ibcmda(C.int(ud), unsafe.Pointer(n), C.size_g(len(cmds))))
-	return
+func Ibcmda(ud int, cmds string) (r Result, err error) {
+	return check("ibcmda", ud, driver().Ibcmda(ud, []byte(cmds)))
+}
+
+// IbcmdaBuf is Ibcmda taking the command bytes as a []byte. The driver
+// is still sending them after IbcmdaBuf returns, so they are copied.
+func IbcmdaBuf(ud int, cmds []byte) (r Result, err error) {
+	return check("ibcmda", ud, driver().Ibcmda(ud, cmds))
 }
 
 // Ibconfig changes software configuration parameters.
 //
 // Changes a configuration item in option to the specified value in
 // v for the selected board or device.
-func Ibconfig(ud, option, v int) (ibsta uint32) {
-	return uint32(C.ibconfig(C.int(ud), C.int(option), C.int(v)))
+func Ibconfig(ud int, option ConfigOption, v int) (r Result, err error) {
+	return check("ibconfig", ud, driver().Ibconfig(ud, option, v))
 }
 
 // Ibdev opens and initialize a device.
 //
 // Acquires a device descriptor to use in subsequent device-level NI-488
 // functions. It opens and initializes a device descriptor, and configures
-// it according to the input parameters. Returns the device descriptor or 1.
-func Ibdev(boardID, pad, sad, tmo, eot, eos int) (dev int) {
-	return int(C.ibdev(C.int(boardID), C.int(pad), C.int(sad),
-		C.int(tmo), C.int(eot), C.int(eos)))
+// it according to the input parameters. Returns the device descriptor or -1
+// and an error.
+func Ibdev(boardID, pad, sad int, tmo Timeout, eot, eos int) (dev int, r Result, err error) {
+	dev, r = driver().Ibdev(boardID, pad, sad, tmo, eot, eos)
+	r, err = check("ibdev", boardID, r)
+	return
+}
+
+// IbdevAddr is Ibdev taking the address of the device as an Address.
+func IbdevAddr(boardID int, addr Address, tmo Timeout, eot, eos int) (dev int, r Result, err error) {
+	return Ibdev(boardID, addr.Primary(), addr.Secondary(), tmo, eot, eos)
 }
 
-// TODO
-//extern int  ibexpert (int ud, int option, void * input, void * Output);
-//func Ibexpert(ud, option int, input, output string) uint32 {
-//	in := C.CString(input)
-//	defer C.free(unsafe.Pointer(in))
-//	return uint32(C.ibexpert(C.int(ud), C.int(option),
-//			unsafe.Pointer(input), unsafe.Pointer(output)))
-//}
+// Ibdiag reads diagnostic information from the board or device.
+//
+// Obsolete, only in ni488.h: fails with ECAP when built against ni4882.h.
+func Ibdiag(ud int, buf []byte) (r Result, err error) {
+	return check("ibdiag", ud, driver().Ibdiag(ud, buf))
+}
+
+// Ibexpert sets or queries advanced options of the board or device.
+//
+// input holds the data passed in for option and output receives the data
+// returned, their layouts depend on option.
+func Ibexpert(ud, option int, input, output []byte) (r Result, err error) {
+	return check("ibexpert", ud, driver().Ibexpert(ud, option, input, output))
+}
 
 // Ibfind opens and initialize a board or a user-configured device descriptor.
 //
@@ -206,30 +282,35 @@ func Ibdev(boardID, pad, sad, tmo, eot, eos int) (dev int) {
 //
 // If ibfind is unable to get a valid descriptor, a -1 is returned; the ERR
 // bit is set in ibsta and iberr contains EDVR.
-func Ibfind(udname string) (ud int) {
-	n := C.CString(udname)
-	defer C.free(unsafe.Pointer(n))
-	return int(C.ibfindA(n))
+func Ibfind(udname string) (ud int, r Result, err error) {
+	ud, r = driver().Ibfind(udname)
+	r, err = check("ibfind", ud, r)
+	return
 }
 
 // Ibgts causes the GPIB board at ud to go to Standby Controller and
 // the GPIB ATN line to be unasserted.
 //
 // v determines whether to perform acceptor handshaking
-func Ibgts(ud, v int) (ibsta uint32) {
-	return uint32(C.ibgts(C.int(ud), C.int(v)))
+func Ibgts(ud, v int) (r Result, err error) {
+	return check("ibgts", ud, driver().Ibgts(ud, v))
 }
 
-// TODO
-//extern int  iblck    (int ud, int v, unsigned int LockWaitTime, void * Reserved);
-//// Acquire or release an exclusive interface lock
-//func Iblck(ud, v int, LockWaitTime uint, Reserved) int {
-//	return int(C.iblck(C.int(ud), C.int(v), C.uint(LockWaitTime), ))
-//}
+// Iblck acquires or releases an exclusive interface lock.
+//
+// If v is non-zero the lock on the interface of ud is acquired for the
+// process, waiting up to lockWaitTime milliseconds for another process
+// to release it, and if v is zero it is released. A lock held elsewhere
+// makes other calls on the interface fail with ELCK.
+func Iblck(ud, v int, lockWaitTime uint) (r Result, err error) {
+	return check("iblck", ud, driver().Iblck(ud, v, lockWaitTime))
+}
 
 // Iblines returns the status of the eight GPIB control lines.
-func Iblines(ud int) (ibsta, result uint32) {
-	return uint32(C.iblines(C.int(ud), (*C.short)(unsafe.Pointer(&result))))
+func Iblines(ud int) (lines uint16, r Result, err error) {
+	l, r := driver().Iblines(ud)
+	r, err = check("iblines", ud, r)
+	return uint16(l), r, err
 }
 
 // Ibln checks for the presence of a device on the bus.
@@ -239,16 +320,39 @@ func Iblines(ud int) (ibsta, result uint32) {
 // then the bus associated with that board is tested for Listeners.
 // If ud is a device descriptor, then ibln uses the access board
 // associated with that device to test for Listeners. If a Listener is
-// detected, a non-zero value is returned in listen. If no Listener is
-// found, zero is returned.
-func Ibln(ud, pad, sad int) (ibsta, listen uint32) {
-	return uint32(C.ibln(C.int(ud), C.int(pad), C.int(sad),
-		(*C.short)(unsafe.Pointer(&listen))))
+// detected, listen is true.
+func Ibln(ud, pad, sad int) (listen bool, r Result, err error) {
+	l, r := driver().Ibln(ud, pad, sad)
+	r, err = check("ibln", ud, r)
+	return l != 0, r, err
+}
+
+// IblnAddr is Ibln taking the address tested as an Address. Testing all
+// the secondary addresses of a primary address takes Ibln with ALL_SAD.
+func IblnAddr(ud int, addr Address) (listen bool, r Result, err error) {
+	return Ibln(ud, addr.Primary(), addr.Secondary())
 }
 
 // Ibloc places the board in local mode if it is not in a lockout state.
-func Ibloc(ud int) (ibsta uint32) {
-	return uint32(C.ibloc(C.int(ud)))
+func Ibloc(ud int) (r Result, err error) {
+	return check("ibloc", ud, driver().Ibloc(ud))
+}
+
+// Iblock acquires the lock on a GPIB-ENET interface.
+//
+// Deprecated: use Iblck. Only in ni488.h: fails with ECAP when built
+// against ni4882.h.
+func Iblock(ud int) (r Result, err error) {
+	return check("iblock", ud, driver().Iblock(ud))
+}
+
+// Iblockx acquires a lock on the interface shared by every process using
+// lockShareName, waiting up to lockWaitTime milliseconds.
+//
+// Deprecated: use Iblck. Only in ni488.h: fails with ECAP when built
+// against ni4882.h.
+func Iblockx(ud, lockWaitTime int, lockShareName string) (r Result, err error) {
+	return check("iblockx", ud, driver().Iblockx(ud, lockWaitTime, lockShareName))
 }
 
 // Ibnotify notifies user of one or more GPIB events by invoking the user
@@ -256,13 +360,22 @@ func Ibloc(ud int) (ibsta uint32) {
 //
 // Installs an asynchronous callback function for a specified
 // board or device. If mask is non-zero, ibnotify monitors the events
-// specified by mask, and when one or more of the events is true, the
-// Callback is invoked. refData User-defined reference data for the callback.
-// int mycallback(int ud, int ibsta, int iberr, long ibcntl, void *RefData)
-func Ibnotify(ud, mask int, f func(), redData []uint32) (ibsta uint32) {
-	return uint32(C.ibnotify(C.int(ud), C.int(mask),
-		(C.GpibNotifyCallback_t)(unsafe.Pointer(&f)),
-		unsafe.Pointer(&redData[0])))
+// specified by mask, and when one or more of the events is true, f is
+// invoked with the descriptor and the ibsta, iberr and ibcntl of the event.
+// f runs on a thread of the driver and is re-armed with mask when it
+// returns. A mask of 0 removes the callback, and f may be nil. If the
+// driver fails to re-arm, f is called once more with ERR set and EARM in
+// iberr. See Notify for a channel of events.
+func Ibnotify(ud int, mask Status, f func(ud int, ibsta Status, iberr ErrorCode, ibcntl int)) (r Result, err error) {
+	var nf NotifyFunc
+	if mask != 0 {
+		nf = func(ud int, ibsta Status, iberr ErrorCode, ibcntl int) Status {
+			f(ud, ibsta, iberr, ibcntl)
+			return mask
+		}
+	}
+	r, _ = callbacks.install(ud, mask, nf)
+	return check("ibnotify", ud, r)
 }
 
 // Ibonl places the device online or offline.
@@ -272,33 +385,52 @@ func Ibnotify(ud, mask int, f func(), redData []uint32) (ibsta uint32) {
 // the device or interface board is taken offline. If v is non-zero, the
 // device or interface board is left operational, or online.
 // ud Board or device descriptor
-func Ibonl(ud, v int) (ibsta int) {
-	return int(C.ibonl(C.int(ud), C.int(v)))
+func Ibonl(ud, v int) (r Result, err error) {
+	return check("ibonl", ud, driver().Ibonl(ud, v))
 }
 
 // Ibpct passes control to another GPIB device with Controller capability.
 //
 // Passes Controller-in-Charge status to the device indicated by ud.
-func Ibpct(ud int) (ibsta uint32) {
-	return uint32(C.ibpct(C.int(ud)))
+func Ibpct(ud int) (r Result, err error) {
+	return check("ibpct", ud, driver().Ibpct(ud))
+}
+
+// Ibpoke sets an internal driver option.
+//
+// Obsolete, only in ni488.h: fails with ECAP when built against ni4882.h.
+func Ibpoke(ud, option, v int) (r Result, err error) {
+	return check("ibpoke", ud, driver().Ibpoke(ud, option, v))
 }
 
 // Ibppc configures parallel polling.
 //
 // If ud is a device descriptor, ibppc enables or disables the device
 // from responding to parallel polls.
-func Ibppc(ud, v int) (ibsta uint32) {
-	return uint32(C.ibppc(C.int(ud), C.int(v)))
+func Ibppc(ud, v int) (r Result, err error) {
+	return check("ibppc", ud, driver().Ibppc(ud, v))
 }
 
-// Ibrd reads data asynchronously from a device into a user buffer.
+// Ibrd reads data from a device into a user buffer.
 //
 // If ud is a device descriptor, ibrd addresses the GPIB, reads up to
 // len(buf) bytes of data, and places the data into the buffer specified
-// buf.
-func Ibrd(ud int, buf []byte) (ibsta uint32) {
-	return uint32(C.ibrd(C.int(ud), unsafe.Pointer(&buf[0]),
-		C.size_g(len(buf))))
+// buf. The number of bytes read is in r.Ibcntl and END is set in r.Ibsta
+// if the read stopped on EOI or EOS; Read returns both, and ReadMessage
+// reads until END.
+func Ibrd(ud int, buf []byte) (r Result, err error) {
+	return check("ibrd", ud, driver().Ibrd(ud, buf))
+}
+
+// Ibrda reads data asynchronously from a device into a user buffer.
+//
+// Starts reading up to len(buf) bytes and returns without waiting for
+// them. buf is filled in once the I/O completes, as reported by Ibwait with
+// CMPL, Ibstop, Ibonl or an Ibnotify callback, and must not be used until
+// then. The actual number of bytes read is returned in ibcntl on completion.
+// See ReadAsync.
+func Ibrda(ud int, buf []byte) (r Result, err error) {
+	return check("ibrda", ud, driver().Ibrda(ud, buf))
 }
 
 // Ibrpp conducts a parallel poll.
@@ -308,45 +440,65 @@ func Ibrd(ud int, buf []byte) (ibsta uint32) {
 // specifying a device, the GPIB Interface board associated with the device
 // conducts the parallel poll. Note that if the GPIB Interface Board to conduct
 // the parallel poll is not the Controller- In-Charge, an ECIC error is generated.
-func Ibrpp(ud int) (ibsta, resp uint32) {
-	return uint32(C.ibrpp(C.int(ud), (*C.char)(unsafe.Pointer(&resp))))
+func Ibrpp(ud int) (ppr byte, r Result, err error) {
+	ppr, r = driver().Ibrpp(ud)
+	r, err = check("ibrpp", ud, r)
+	return
 }
 
 // Ibrsp conducts a serial poll on the device ud.
-func Ibrsp(ud int) (ibsta, resp uint32) {
-	return uint32(C.ibrsp(C.int(ud), (*C.char)(unsafe.Pointer(&resp))))
+func Ibrsp(ud int) (spr byte, r Result, err error) {
+	spr, r = driver().Ibrsp(ud)
+	r, err = check("ibrsp", ud, r)
+	return
 }
 
 // Ibsic asserts an interface clear.
 //
 // Asserts the GPIB interfaces clear (IFC) line for at least 100s
 // if the GPIB board is System Controller.
-func Ibsic(ud int) (ibsta uint32) {
-	return uint32(C.ibsic(C.int(ud)))
+func Ibsic(ud int) (r Result, err error) {
+	return check("ibsic", ud, driver().Ibsic(ud))
 }
 
 // Ibstop aborts an asynchronous I/O operation.
 //
 // Aborts any asynchronous read, write, or command operation that is in
 // progress and resynchronizes the application with the driver.
-func Ibstop(ud int) (ibsta uint32) {
-	return uint32(C.ibstop(C.int(ud)))
+func Ibstop(ud int) (r Result, err error) {
+	return check("ibstop", ud, driver().Ibstop(ud))
 }
 
 // Ibtrg triggers the selected device.
 //
 // Sends the Group Execute Trigger (GET) message to the device
 // described by ud.
-func Ibtrg(ud int) (ibsta uint32) {
-	return uint32(C.ibtrg(C.int(ud)))
+func Ibtrg(ud int) (r Result, err error) {
+	return check("ibtrg", ud, driver().Ibtrg(ud))
+}
+
+// Ibunlock releases the lock taken by Iblock.
+//
+// Deprecated: use Iblck. Only in ni488.h: fails with ECAP when built
+// against ni4882.h.
+func Ibunlock(ud int) (r Result, err error) {
+	return check("ibunlock", ud, driver().Ibunlock(ud))
+}
+
+// Ibunlockx releases the lock taken by Iblockx.
+//
+// Deprecated: use Iblck. Only in ni488.h: fails with ECAP when built
+// against ni4882.h.
+func Ibunlockx(ud int) (r Result, err error) {
+	return check("ibunlockx", ud, driver().Ibunlockx(ud))
 }
 
 // Ibwait waits for GPIB events.
 //
 // Monitors the events specified by mask and delays processing until
 // one or more of the events occurs.
-func Ibwait(ud, mask int) (ibsta uint32) {
-	return uint32(C.ibwait(C.int(ud), C.int(mask)))
+func Ibwait(ud int, mask Status) (r Result, err error) {
+	return check("ibwait", ud, driver().Ibwait(ud, mask))
 }
 
 // Ibwrt writes data to a device from a user buffer.
@@ -356,10 +508,16 @@ func Ibwait(ud, mask int) (ibsta uint32) {
 // If ud is a board descriptor, ibwrt writes len(buf) bytes of data from the
 // buffer specified by buf to a GPIB device; a board-level ibwrt assumes that
 // the GPIB is already properly addressed.
-func Ibwrt(ud int, buf string) (ibsta uint32) {
-	n := C.CString(buf)
-	defer C.free(unsafe.Pointer(n))
-	return uint32(C.ibwrt(C.int(ud), unsafe.Pointer(n), C.size_g(len(buf))))
+func Ibwrt(ud int, buf string) (r Result, err error) {
+	return check("ibwrt", ud, driver().Ibwrt(ud, []byte(buf)))
+}
+
+// IbwrtBuf is Ibwrt taking the data as a []byte, which is passed to the
+// driver without a copy, so binary data needs no string conversion and a
+// write allocates nothing. It returns the number of bytes written.
+func IbwrtBuf(ud int, buf []byte) (n int, r Result, err error) {
+	r, err = check("ibwrt", ud, driver().Ibwrt(ud, buf))
+	return r.count(len(buf)), r, err
 }
 
 // Ibwrta writes data asynchronously to a device from a user buffer.
@@ -369,10 +527,15 @@ func Ibwrt(ud int, buf string) (ibsta uint32) {
 // If ud is a board descriptor, ibwrt writes len(buf) bytes of data from the
 // buffer specified by buf to a GPIB device; a board-level ibwrt assumes that
 // the GPIB is already properly addressed.
-func Ibwrta(ud int, buf string) (ibsta uint32) {
-	n := C.CString(buf)
-	defer C.free(unsafe.Pointer(n))
-	return uint32(C.ibwrta(C.int(ud), unsafe.Pointer(n), C.size_g(len(buf))))
+func Ibwrta(ud int, buf string) (r Result, err error) {
+	return check("ibwrta", ud, driver().Ibwrta(ud, []byte(buf)))
+}
+
+// IbwrtaBuf is Ibwrta taking the data as a []byte. The driver is still
+// writing it after IbwrtaBuf returns, so it is copied; the number of bytes
+// written is in the ibcntl of the Ibwait reporting CMPL.
+func IbwrtaBuf(ud int, buf []byte) (r Result, err error) {
+	return check("ibwrta", ud, driver().Ibwrta(ud, buf))
 }
 
 // Ibwrtf writes data to a device from a file.
@@ -380,11 +543,9 @@ func Ibwrta(ud int, buf string) (ibsta uint32) {
 // If ud is a device descriptor, ibwrtf addresses the GPIB and writes all
 // of the bytes from the file filename to a GPIB device. If ud is a board
 // descriptor, ibwrtf writes all of the bytes of data from the file filename
-// to a GPIB device.
-func Ibwrtf(ud int, filename string) (ibsta uint32) {
-	n := C.CString(filename)
-	defer C.free(unsafe.Pointer(n))
-	return uint32(C.ibwrtfA(C.int(ud), n))
+// to a GPIB device. See WriteFrom for writing from any io.Reader.
+func Ibwrtf(ud int, filename string) (r Result, err error) {
+	return check("ibwrtf", ud, driver().Ibwrtf(ud, filename))
 }
 
 // Ibdma enables or disables DMA.
@@ -392,9 +553,19 @@ func Ibwrtf(ud int, filename string) (ibsta uint32) {
 // Enables or disables DMA transfers for the board, according to v.
 // If v is zero, then DMA is not used for GPIB I/O transfers, and if v
 // is non-zero, then DMA is used for GPIB I/O transfers.
-func Ibdma(ud, v int) (ibsta int) {
-	ibsta = int(C.ibconfig(C.int(ud), C.int(C.IbcDMA), C.int(v)))
-	return
+func Ibdma(ud, v int) (r Result, err error) {
+	return check("ibconfig", ud, driver().Ibconfig(ud, IbcDMA, v))
+}
+
+// Ibeos configures the EOS termination mode or EOS character for the board
+// or device.
+//
+// The parameter v describes the new end-of-string (EOS)
+// configuration to use. If v is zero, then the EOS configuration is
+// disabled. Otherwise, the low byte is the EOS character and the upper
+// byte contains flags which define the EOS mode.
+func Ibeos(ud, v int) (r Result, err error) {
+	return check("ibeos", ud, driver().Ibeos(ud, v))
 }
 
 // Ibeot enables or disables the assertion of the EOI line at the end of
@@ -402,29 +573,29 @@ func Ibdma(ud, v int) (ibsta int) {
 //
 // If v is non-zero, then EOI is asserted when the last byte of a GPIB
 // write is sent.
-func Ibeot(ud, v int) (ibsta int) {
-	return int(C.ibconfig(C.int(ud), C.int(C.IbcEOT), C.int(v)))
+func Ibeot(ud, v int) (r Result, err error) {
+	return check("ibconfig", ud, driver().Ibconfig(ud, IbcEOT, v))
 }
 
 // Ibist sets or clears the board individual status bit for parallel polls.
-func Ibist(ud, v int) (ibsta int) {
-	return int(C.ibconfig(C.int(ud), C.int(C.IbcIst), C.int(v)))
+func Ibist(ud, v int) (r Result, err error) {
+	return check("ibconfig", ud, driver().Ibconfig(ud, IbcIst, v))
 }
 
 // Ibpad changes the primary address.
 //
 // Sets the primary GPIB address of the board or device to v, an
 // integer ranging from 0 to 30.
-func Ibpad(ud, v int) (ibsta int) {
-	return int(C.ibconfig(C.int(ud), C.int(C.IbcPAD), C.int(v)))
+func Ibpad(ud, v int) (r Result, err error) {
+	return check("ibconfig", ud, driver().Ibconfig(ud, IbcPAD, v))
 }
 
 // Ibrsc requests or releases system control.
 //
 // Requests or releases the capability to send Interface Clear (IFC)
 // and Remote Enable (REN) messages to devices.
-func Ibrsc(ud, v int) (ibsta int) {
-	return int(C.ibconfig(C.int(ud), C.int(C.IbcSC), C.int(v)))
+func Ibrsc(ud, v int) (r Result, err error) {
+	return check("ibconfig", ud, driver().Ibconfig(ud, IbcSC, v))
 }
 
 // Ibrsv requests service and change the serial poll status byte.
@@ -432,58 +603,55 @@ func Ibrsc(ud, v int) (ibsta int) {
 // Is used to request service from the Controller and to provide the
 // Controller with an application-dependent status byte when the
 // Controller serial polls the GPIB board.
-func Ibrsv(ud, status int) (ibsta int) {
-	return int(C.ibconfig(C.int(ud), C.int(C.IbcRsv), C.int(status)))
+func Ibrsv(ud, status int) (r Result, err error) {
+	return check("ibconfig", ud, driver().Ibconfig(ud, IbcRsv, status))
 }
 
 // Ibsad changes or disables the secondary address.
 //
 // Changes the secondary GPIB address of the given board or device
 // to v, an integer in the range 96 to 126 (hex 60 to hex 7E) or zero.
-func Ibsad(ud, v int) (ibsta int) {
-	return int(C.ibconfig(C.int(ud), C.int(C.IbcSAD), C.int(v)))
+func Ibsad(ud, v int) (r Result, err error) {
+	return check("ibconfig", ud, driver().Ibconfig(ud, IbcSAD, v))
 }
 
 // Ibsre sets or clears the Remote Enable (REN) line.
 //
 // If v is non-zero, the GPIB Remote Enable (REN) line is asserted.
 // If v is zero, REN is unasserted.
-func Ibsre(ud, v int) (ibsta int) {
-	return int(C.ibconfig(C.int(ud), C.int(C.IbcSRE), C.int(v)))
+func Ibsre(ud, v int) (r Result, err error) {
+	return check("ibconfig", ud, driver().Ibconfig(ud, IbcSRE, v))
 }
 
 // Ibtmo changes or disables the timeout period.
 //
 // Sets the timeout period of the board or device to v.
-func Ibtmo(ud, v int) (ibsta int) {
-	return int(C.ibconfig(C.int(ud), C.int(C.IbcTMO), C.int(v)))
+func Ibtmo(ud int, v Timeout) (r Result, err error) {
+	return check("ibconfig", ud, driver().Ibconfig(ud, IbcTMO, int(v)))
 }
 
 //  NI-488.2 Functions
 
-// XXX: AllSpoll is undefined when linking against ni4882.dll
-//      and gpib-32.dll (32-bit compilation via 8g&8l)
-
 // AllSpoll performs a serial poll on all devices.
 //
 // Serial polls all of the devices described by addrlist. It
-// stores the poll responses in resultlist and the number of responses
-// in ibcntl.
-//func AllSpoll(boardID int, addrlist []int16) (results []int16) {
-//	n := append(addrlist, NOADDR)
-//	results = make([]int16, len(addrlist))
-//	C.AllSpoll(C.int(boardID), (*C.short)(&n[0]),
-//		(*C.short)(&results[0]))
-//	return
-//}
+// stores the poll responses in results and the number of responses
+// in ibcntl. If a device fails to respond, ibcntl holds its index in
+// addrlist. AllSpoll is missing from the 32-bit libraries, there the
+// devices are polled one at a time with ReadStatusByte.
+func AllSpoll(boardID int, addrlist []Address) (results []int16, r Result, err error) {
+	results = make([]int16, len(addrlist))
+	r, err = check("AllSpoll", boardID, driver().AllSpoll(boardID, addrlist, results))
+	return
+}
 
 // DevClear clears a single device.
 //
 // Sends the Selected Device Clear (SDC) GPIB message to the device
 // described by address. If address is the constant NOADDR, then the Universal
 // Device Clear (DCL) message is sent to all devices.
-func DevClear(boardID, address int) {
-	C.DevClear(C.int(boardID), C.short(address))
+func DevClear(boardID int, address Address) (r Result, err error) {
+	return check("DevClear", boardID, driver().DevClear(boardID, address))
 }
 
 // DevClearList clears multiple devices.
@@ -492,9 +660,8 @@ func DevClear(boardID, address int) {
 // the device addresses described by addrlist. If addrlist contains only the
 // constant NOADDR, then the Universal Device Clear (DCL) message is sent to
 // all the devices on the bus.
-func DevClearList(boardID int, addrlist []int16) {
-	n := append(addrlist, NOADDR)
-	C.DevClearList(C.int(boardID), (*C.short)(&n[0]))
+func DevClearList(boardID int, addrlist []Address) (r Result, err error) {
+	return check("DevClearList", boardID, driver().DevClearList(boardID, addrlist))
 }
 
 // EnableLocal enables operations from the front panel of devices (leave
@@ -504,18 +671,16 @@ func DevClearList(boardID int, addrlist []int16) {
 // described by addrlist. This places the devices into local mode. If addrlist
 // contains only the constant NOADDR, then the Remote Enable (REN) GPIB line
 // is unasserted.
-func EnableLocal(boardID int, addrlist []int16) {
-	n := append(addrlist, NOADDR)
-	C.EnableLocal(C.int(boardID), (*C.short)(&n[0]))
+func EnableLocal(boardID int, addrlist []Address) (r Result, err error) {
+	return check("EnableLocal", boardID, driver().EnableLocal(boardID, addrlist))
 }
 
 // EnableRemote enables a remote GPIB programming for devices.
 //
 // Asserts the Remote Enable (REN) GPIB line. All devices
 // described by addrlist are put into a listen-active state.
-func EnableRemote(boardID int, addrlist []int16) {
-	n := append(addrlist, NOADDR)
-	C.EnableLocal(C.int(boardID), (*C.short)(&n[0]))
+func EnableRemote(boardID int, addrlist []Address) (r Result, err error) {
+	return check("EnableRemote", boardID, driver().EnableRemote(boardID, addrlist))
 }
 
 // FindLstn finds listening devices on GPIB.
@@ -525,13 +690,12 @@ func EnableRemote(boardID int, addrlist []int16) {
 // address is stored in results, otherwise, all secondary addresses of the
 // primary address are tested, and the addresses of any devices found are
 // stored in results. No more than limit addresses are stored in results.
-// ibcntl contains the actual number of addresses stored in results.
-func FindLstn(boardID int, addrlist []int16, limit int) (results []int16) {
-	n := append(addrlist, NOADDR)
-	results = make([]int16, limit)
-	C.FindLstn(C.int(boardID), (*C.short)(&n[0]),
-		(*C.short)(&results[0]), C.int(limit))
-	return
+// ibcntl contains the actual number of addresses stored in results, and
+// results is sliced to that length.
+func FindLstn(boardID int, addrlist []Address, limit int) (results []Address, r Result, err error) {
+	results = make([]Address, limit)
+	r, err = check("FindLstn", boardID, driver().FindLstn(boardID, addrlist, results))
+	return results[:r.count(limit)], r, err
 }
 
 // FindRQS determines which device is requesting service.
@@ -542,10 +706,9 @@ func FindLstn(boardID int, addrlist []int16, limit int) (results []int16) {
 // service in addrlist. If none of the devices are requesting service, then
 // the index corresponding to NOADDR in addrlist is returned in ibcntl and
 // ETAB is returned in iberr.
-func FindRQS(boardID int, padList []int16) (status int16) {
-	n := append(padList, NOADDR)
-	C.FindRQS(C.int(boardID), (*C.short)(&n[0]),
-		(*C.short)(&status))
+func FindRQS(boardID int, padList []Address) (status int16, r Result, err error) {
+	status, r = driver().FindRQS(boardID, padList)
+	r, err = check("FindRQS", boardID, r)
 	return
 }
 
@@ -554,8 +717,9 @@ func FindRQS(boardID int, padList []int16) (status int16) {
 // Conducts a parallel poll and the result is placed in status. Each of
 // the eight bits of result represents the status information for each device
 // configured for a parallel poll.
-func PPoll(boardID int) (status int16) {
-	C.PPoll(C.int(boardID), (*C.short)(&status))
+func PPoll(boardID int) (status int16, r Result, err error) {
+	status, r = driver().PPoll(boardID)
+	r, err = check("PPoll", boardID, r)
 	return
 }
 
@@ -566,9 +730,8 @@ func PPoll(boardID int) (status int16) {
 // lineSense equals the individual status (ist) bit of the device, then the
 // assigned GPIB data line is asserted during a parallel poll, otherwise, the
 // data line is not asserted during a parallel poll.
-func PPollConfig(boardID, dataLine, lineSense, addr int16) {
-	C.PPollConfig(C.int(boardID), C.short(addr),
-		C.int(dataLine), C.int(lineSense))
+func PPollConfig(boardID, dataLine, lineSense int, addr Address) (r Result, err error) {
+	return check("PPollConfig", boardID, driver().PPollConfig(boardID, addr, dataLine, lineSense))
 }
 
 // PPollUnconfig unconfigures devices for parallel polls.
@@ -578,9 +741,8 @@ func PPollConfig(boardID, dataLine, lineSense, addr int16) {
 // Unconfigure (PPU) GPIB message is sent to all GPIB devices. The devices
 // unconfigured by this function do not participate in subsequent parallel polls.
 // boardID The interface board number.
-func PPollUnconfig(boardID int, addrlist []int16) {
-	n := append(addrlist, NOADDR)
-	C.PPollUnconfig(C.int(boardID), (*C.short)(&n[0]))
+func PPollUnconfig(boardID int, addrlist []Address) (r Result, err error) {
+	return check("PPollUnconfig", boardID, driver().PPollUnconfig(boardID, addrlist))
 }
 
 // PassControl passes control to another device with Controller capability.
@@ -588,8 +750,8 @@ func PPollUnconfig(boardID int, addrlist []int16) {
 // Sends the Take Control (TCT) GPIB message to the device
 // described by addr. The device becomes Controller-In-Charge and the
 // interface board is no longer CIC.
-func PassControl(boardID, addr int16) {
-	C.PassControl(C.int(boardID), C.short(addr))
+func PassControl(boardID int, addr Address) (r Result, err error) {
+	return check("PassControl", boardID, driver().PassControl(boardID, addr))
 }
 
 // RcvRespMsg reads data bytes from a device that is already addressed to talk.
@@ -600,25 +762,24 @@ func PassControl(boardID, addr int16) {
 // is STOPend, the read is stopped when a byte is received with the EOI line
 // asserted. Otherwise, the read is stopped when the 8-bit EOS character is
 // detected. The actual number of bytes transferred is returned in the global
-// variable, ibcntl.
+// variable, ibcntl, and data is sliced to that length.
 //
 // Assumes that the interface board is already in its listen-active
 // state and a device is already addressed to be a Talker (see ReceiveSetup
 // or Receive).
-func RcvRespMsg(boardID, count, Termination int) (data []byte) {
+func RcvRespMsg(boardID, count, Termination int) (data []byte, r Result, err error) {
 	data = make([]byte, count)
-	C.RcvRespMsg(C.int(boardID), unsafe.Pointer(&data[0]),
-		C.size_g(count), C.int(Termination))
-	return
+	r, err = check("RcvRespMsg", boardID, driver().RcvRespMsg(boardID, data, Termination))
+	return data[:r.count(count)], r, err
 }
 
 // ReadStatusByte serial poll a single device.
 //
 // Serial polls the device described by addr. The response
 // byte is stored in result.
-func ReadStatusByte(boardID, addr int16) (result int16) {
-	C.ReadStatusByte(C.int(boardID), C.short(addr),
-		(*C.short)(&result))
+func ReadStatusByte(boardID int, addr Address) (result int16, r Result, err error) {
+	result, r = driver().ReadStatusByte(boardID, addr)
+	r, err = check("ReadStatusByte", boardID, r)
 	return
 }
 
@@ -631,12 +792,11 @@ func ReadStatusByte(boardID, addr int16) (result int16) {
 // the read is stopped when a byte is received with the EOI line asserted.
 // Otherwise, the read is stopped when an 8-bit EOS character is detected.
 // The actual number of bytes transferred is returned in the global variable,
-// ibcntl.
-func Receive(boardID, count, Termination, addr int16) (data []byte) {
+// ibcntl, and data is sliced to that length.
+func Receive(boardID, count, Termination int, addr Address) (data []byte, r Result, err error) {
 	data = make([]byte, count)
-	C.Receive(C.int(boardID), C.short(addr), unsafe.Pointer(&data[0]),
-		C.size_g(count), C.int(Termination))
-	return
+	r, err = check("Receive", boardID, driver().Receive(boardID, addr, data, Termination))
+	return data[:r.count(count)], r, err
 }
 
 // ReceiveSetup addresses a device to be a Talker and the interface board
@@ -645,8 +805,8 @@ func Receive(boardID, count, Termination, addr int16) (data []byte) {
 // Makes the device described by addr talk-active, and makes
 // the interface board listen-active. This call is usually followed by a call
 // to RcvRespMsg to transfer data from the device to the interface board.
-func ReceiveSetup(boardID, addr int16) {
-	C.ReceiveSetup(C.int(boardID), C.short(addr))
+func ReceiveSetup(boardID int, addr Address) (r Result, err error) {
+	return check("ReceiveSetup", boardID, driver().ReceiveSetup(boardID, addr))
 }
 
 // ResetSys resets and initializes IEEE 488.2-compliant devices.
@@ -658,9 +818,8 @@ func ReceiveSetup(boardID, addr int16) {
 // causes IEEE 488.2-compliant devices to perform device-specific reset and
 // initialization. This step is accomplished by sending the message "*RST\n"
 // to the devices described by addrlist.
-func ResetSys(boardID int, addrlist []int16) {
-	n := append(addrlist, NOADDR)
-	C.ResetSys(C.int(boardID), (*C.short)(&n[0]))
+func ResetSys(boardID int, addrlist []Address) (r Result, err error) {
+	return check("ResetSys", boardID, driver().ResetSys(boardID, addrlist))
 }
 
 // Send sends data bytes to a device.
@@ -671,11 +830,15 @@ func ResetSys(boardID int, addrlist []int16) {
 // NULLend. If eotmode is NLend then a new line character ('\n') is sent with
 // the EOI line asserted after the last byte of buffer. The actual number of
 // bytes transferred is returned in the global variable, ibcntl.
-func Send(boardID, eotMode int, addr int16, cmds string) {
-	n := C.CString(cmds)
-	defer C.free(unsafe.Pointer(n))
-	C.Send(C.int(boardID), C.short(addr), unsafe.Pointer(n),
-		C.size_g(len(cmds)), C.int(eotMode))
+func Send(boardID, eotMode int, addr Address, cmds string) (r Result, err error) {
+	return check("Send", boardID, driver().Send(boardID, addr, []byte(cmds), eotMode))
+}
+
+// SendBuf is Send taking the data as a []byte, which is passed to the
+// driver without a copy. It returns the number of bytes sent.
+func SendBuf(boardID, eotMode int, addr Address, data []byte) (n int, r Result, err error) {
+	r, err = check("Send", boardID, driver().Send(boardID, addr, data, eotMode))
+	return r.count(len(data)), r, err
 }
 
 // SendCmds sends GPIB command bytes.
@@ -687,10 +850,15 @@ func Send(boardID, eotMode int, addr int16, cmds string) {
 // Use command bytes to configure the state of the GPIB, not to send
 // instructions to GPIB devices. Use Send or SendList to send device-specific
 // instructions.
-func SendCmds(boardID int, cmds string) {
-	n := C.CString(cmds)
-	defer C.free(unsafe.Pointer(n))
-	C.SendCmds(C.int(boardID), unsafe.Pointer(n), C.size_g(len(cmds)))
+func SendCmds(boardID int, cmds string) (r Result, err error) {
+	return check("SendCmds", boardID, driver().SendCmds(boardID, []byte(cmds)))
+}
+
+// SendCmdsBuf is SendCmds taking the command bytes as a []byte, which is
+// passed to the driver without a copy. It returns the number of bytes sent.
+func SendCmdsBuf(boardID int, cmds []byte) (n int, r Result, err error) {
+	r, err = check("SendCmds", boardID, driver().SendCmds(boardID, cmds))
+	return r.count(len(cmds)), r, err
 }
 
 // SendDataBytes sends cmd bytes to devices that are already addressed to listen.
@@ -705,12 +873,15 @@ func SendCmds(boardID int, cmds string) {
 // Assumes that the interface board is in talk-active state and
 // that devices are already addressed as Listeners on the GPIB (see SendSetup,
 // Send, or SendList).
-func SendDataBytes(boardID, eotMode int, cmds string) {
-	n := C.CString(cmds)
-	defer C.free(unsafe.Pointer(n))
-	C.SendDataBytes(C.int(boardID), unsafe.Pointer(n), C.size_g(len(cmds)),
-		C.int(eotMode))
-	return
+func SendDataBytes(boardID, eotMode int, cmds string) (r Result, err error) {
+	return check("SendDataBytes", boardID, driver().SendDataBytes(boardID, []byte(cmds), eotMode))
+}
+
+// SendDataBytesBuf is SendDataBytes taking the data as a []byte, which is
+// passed to the driver without a copy. It returns the number of bytes sent.
+func SendDataBytesBuf(boardID, eotMode int, data []byte) (n int, r Result, err error) {
+	r, err = check("SendDataBytes", boardID, driver().SendDataBytes(boardID, data, eotMode))
+	return r.count(len(data)), r, err
 }
 
 // SendIFC resets the GPIB by sending interface clear.
@@ -719,8 +890,8 @@ func SendDataBytes(boardID, eotMode int, cmds string) {
 // board to be Controller-In-Charge of the GPIB. It also ensures that the
 // connected devices are all unaddressed and that the interface functions of
 // the devices are in their idle states.
-func SendIFC(boardID int) {
-	C.SendIFC(C.int(boardID))
+func SendIFC(boardID int) (r Result, err error) {
+	return check("SendIFC", boardID, driver().SendIFC(boardID))
 }
 
 // SendLLO sends the Local Lockout (LLO) message to all devices.
@@ -728,8 +899,8 @@ func SendIFC(boardID int) {
 // Sends the GPIB Local Lockout (LLO) message to all devices. While
 // Local Lockout is in effect, only the Controller-In-Charge can alter the
 // state of the devices by sending appropriate GPIB messages.
-func SendLLO(boardID int) {
-	C.SendLLO(C.int(boardID))
+func SendLLO(boardID int) (r Result, err error) {
+	return check("SendLLO", boardID, driver().SendLLO(boardID))
 }
 
 // SendList sends data bytes to multiple GPIB devices.
@@ -740,22 +911,27 @@ func SendLLO(boardID int) {
 // DABend. The last byte is sent without the EOI line asserted if eotMode is
 // NULLend. If eotMode is NLend, then a new line character ('\n') is sent with
 // the EOI line asserted after the last byte. The actual number of bytes
-// transferred is returned in the global variable, ibcntl.
-func SendList(boardID, count, eotMode int, addrlist []int16, data []byte) {
-	n := append(addrlist, NOADDR)
-	C.SendList(C.int(boardID), (*C.short)(&n[0]),
-		unsafe.Pointer(&data[0]), C.size_g(count), C.int(eotMode))
+// transferred is returned in the global variable, ibcntl. A count outside
+// 0 to len(data) fails with EARG.
+func SendList(boardID, count, eotMode int, addrlist []Address, data []byte) (r Result, err error) {
+	if count < 0 || count > len(data) {
+		return check("SendList", boardID, badCount)
+	}
+	return check("SendList", boardID, driver().SendList(boardID, addrlist, data[:count], eotMode))
 }
 
+// badCount is the Result of a call given a count outside its buffer, which
+// is not made.
+var badCount = Result{Ibsta: ERR, Iberr: EARG}
+
 // SendSetup sets up devices to receive data in preparation for SendDataBytes.
 //
 // Makes the devices described by addrlist listen-active and makes
 // the interface board talk-active. This call is usually followed by
 // SendDataBytes to actually transfer data from the interface board to the
 // devices.
-func SendSetup(boardID int, addrlist []int16) {
-	n := append(addrlist, NOADDR)
-	C.SendSetup(C.int(boardID), (*C.short)(&n[0]))
+func SendSetup(boardID int, addrlist []Address) (r Result, err error) {
+	return check("SendSetup", boardID, driver().SendSetup(boardID, addrlist))
 }
 
 // SetRWLS places devices in remote with lockout state.
@@ -765,9 +941,8 @@ func SendSetup(boardID int, addrlist []int16) {
 // in lockout state by the Local Lockout (LLO) GPIB message. You cannot program
 // those devices locally until the Controller-In-Charge releases the Local
 // Lockout by way of the EnableLocal NI-488.2 routine.
-func SetRWLS(boardID int, addrlist []int16) {
-	n := append(addrlist, NOADDR)
-	C.SetRWLS(C.int(boardID), (*C.short)(&n[0]))
+func SetRWLS(boardID int, addrlist []Address) (r Result, err error) {
+	return check("SetRWLS", boardID, driver().SetRWLS(boardID, addrlist))
 }
 
 // TestSRQ determines the current state of the GPIB Service Request (SRQ) line.
@@ -775,8 +950,9 @@ func SetRWLS(boardID int, addrlist []int16) {
 // Returns the current state of the GPIB SRQ line in result. If SRQ is
 // asserted, then result contains a non-zero value, otherwise, result is
 // zero.
-func TestSRQ(boardID int) (result int16) {
-	C.TestSRQ(C.int(boardID), (*C.short)(&result))
+func TestSRQ(boardID int) (result int16, r Result, err error) {
+	result, r = driver().TestSRQ(boardID)
+	r, err = check("TestSRQ", boardID, r)
 	return
 }
 
@@ -793,11 +969,9 @@ func TestSRQ(boardID int) (result int16) {
 // devices that failed. Otherwise, the meaning of ibcntl depends on the error
 // returned. If a device fails to send a response before the timeout period
 // expires, a test result of 1 is reported for it, and the error EABO is returned.
-func TestSys(boardID int, addrlist []int16) (results []int16) {
-	n := append(addrlist, NOADDR)
+func TestSys(boardID int, addrlist []Address) (results []int16, r Result, err error) {
 	results = make([]int16, len(addrlist))
-	C.TestSys(C.int(boardID), (*C.short)(&n[0]),
-		(*C.short)(&results[0]))
+	r, err = check("TestSys", boardID, driver().TestSys(boardID, addrlist, results))
 	return
 }
 
@@ -806,8 +980,8 @@ func TestSys(boardID int, addrlist []int16) (results []int16) {
 // Sends the Group Execute Trigger (GET) GPIB message to the device
 // described by addr. If address is the constant NOADDR, then the GET message
 // is sent to all devices that are currently listen-active on the GPIB.
-func Trigger(boardID int, addr int16) {
-	C.Trigger(C.int(boardID), C.short(addr))
+func Trigger(boardID int, addr Address) (r Result, err error) {
+	return check("Trigger", boardID, driver().Trigger(boardID, addr))
 }
 
 // TriggerList triggers multiple devices.
@@ -816,9 +990,8 @@ func Trigger(boardID int, addr int16) {
 // described by addrlist. If the only address in addrlist is the constant NOADDR,
 // then no addressing is performed and the GET message is sent to all devices
 // that are currently listen-active on the GPIB.
-func TriggerList(boardID int, addrlist []int16) {
-	n := append(addrlist, NOADDR)
-	C.TriggerList(C.int(boardID), (*C.short)(&n[0]))
+func TriggerList(boardID int, addrlist []Address) (r Result, err error) {
+	return check("TriggerList", boardID, driver().TriggerList(boardID, addrlist))
 }
 
 // WaitSRQ waita until a device asserts the GPIB Service Request (SRQ) line.
@@ -826,7 +999,8 @@ func TriggerList(boardID int, addrlist []int16) {
 // Waits until either the GPIB SRQ line is asserted or the timeout
 // period has expired (see ibtmo). When WaitSRQ returns, result is non-zero
 // if SRQ is asserted, otherwise, result is zero.
-func WaitSRQ(boardID int) (result int16) {
-	C.WaitSRQ(C.int(boardID), (*C.short)(&result))
+func WaitSRQ(boardID int) (result int16, r Result, err error) {
+	result, r = driver().WaitSRQ(boardID)
+	r, err = check("WaitSRQ", boardID, r)
 	return
 }
@@ -0,0 +1,74 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+//go:build !nogpib
+// +build !nogpib
+
+package ni488
+
+/*
+#include <stdlib.h>
+#include <string.h>
+*/
+import "C"
+import (
+	"sync"
+	"unsafe"
+)
+
+// The driver goes on using the buffer of ibrda, ibwrta and ibcmda after
+// the call returns, which Go memory must not be used for. Those calls are
+// given C memory instead, held here until the I/O completes.
+var pending = asyncRegistry{bufs: make(map[int]asyncBuf)}
+
+type asyncBuf struct {
+	c   unsafe.Pointer
+	dst []byte // where the data read goes, nil for writes
+}
+
+type asyncRegistry struct {
+	sync.Mutex
+	bufs map[int]asyncBuf // the I/O in progress on each descriptor
+}
+
+// start records c as the buffer of the I/O started on ud with result r,
+// and returns r. The buffer is released right away if the I/O failed to
+// start or has already completed.
+func (a *asyncRegistry) start(ud int, c unsafe.Pointer, dst []byte, r Result) Result {
+	b := asyncBuf{c, dst}
+	switch {
+	case r.Ibsta&ERR != 0:
+		C.free(c)
+	case r.Ibsta&CMPL != 0:
+		b.done(r.Ibcntl)
+	default:
+		a.Lock()
+		a.bufs[ud] = b
+		a.Unlock()
+	}
+	return r
+}
+
+// finish completes the I/O in progress on ud, if any, having transferred
+// n bytes.
+func (a *asyncRegistry) finish(ud, n int) {
+	a.Lock()
+	b, ok := a.bufs[ud]
+	delete(a.bufs, ud)
+	a.Unlock()
+	if ok {
+		b.done(n)
+	}
+}
+
+// done copies the n bytes read into dst and releases the C buffer.
+func (b asyncBuf) done(n int) {
+	if n > len(b.dst) {
+		n = len(b.dst)
+	}
+	if n > 0 {
+		C.memcpy(unsafe.Pointer(&b.dst[0]), b.c, C.size_t(n))
+	}
+	C.free(b.c)
+}
@@ -0,0 +1,784 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+//go:build !nogpib
+// +build !nogpib
+
+package ni488
+
+/*
+#cgo linux,!fakegpib LDFLAGS: -lgpibapi
+#cgo fakegpib CFLAGS: -I${SRCDIR}
+#cgo darwin CFLAGS: -I.
+#cgo darwin LDFLAGS: -framework NI488
+#cgo windows CFLAGS: -I.
+#cgo windows LDFLAGS: -lgpib-32 -LC:/WINDOWS/system32
+#include <stddef.h>
+#include <stdint.h>
+#include <stdlib.h>
+#if defined(__amd64) || defined(__amd64__) || defined(__x86_64) || defined(__x86_64__) && !defined(__APPLE__)
+#define size_g size_t
+#include <ni4882.h>
+#else
+#define size_g long
+#include <ni488.h>
+#endif
+
+// The W variants of the routines taking names take wchar_t strings in
+// ni4882.h and unsigned short strings in ni488.h.
+#ifdef NI488CC
+typedef wchar_t go_wchar;
+#else
+typedef unsigned short go_wchar;
+#endif
+
+// go_result holds the thread-specific status left behind by a call. Each
+// go_ wrapper makes its call and captures the status in the same cgo
+// crossing, so nothing else can run on the thread in between.
+typedef struct {
+	unsigned long sta, err, cntl;
+} go_result;
+
+#define GO_CALL(name, params, args) \
+	static void go_##name params { \
+		name args; \
+		r->sta = ThreadIbsta(); \
+		r->err = ThreadIberr(); \
+		r->cntl = ThreadIbcnt(); \
+	}
+
+// ibeos is a function in ni488.h and a macro in ni4882.h, and the FindLstn
+// limit is an int in ni488.h and a size_t in ni4882.h; both are taken care
+// of by the wrappers.
+GO_CALL(ibask, (go_result *r, int ud, int option, int *v), (ud, option, v))
+GO_CALL(ibcac, (go_result *r, int ud, int v), (ud, v))
+GO_CALL(ibclr, (go_result *r, int ud), (ud))
+GO_CALL(ibcmd, (go_result *r, int ud, void *buf, size_g cnt), (ud, buf, cnt))
+GO_CALL(ibcmda, (go_result *r, int ud, void *buf, size_g cnt), (ud, buf, cnt))
+GO_CALL(ibconfig, (go_result *r, int ud, int option, int v), (ud, option, v))
+GO_CALL(ibeos, (go_result *r, int ud, int v), (ud, v))
+GO_CALL(ibexpert, (go_result *r, int ud, int option, void *input, void *output), (ud, option, input, output))
+GO_CALL(ibgts, (go_result *r, int ud, int v), (ud, v))
+GO_CALL(iblck, (go_result *r, int ud, int v, unsigned int wait), (ud, v, wait, NULL))
+GO_CALL(iblines, (go_result *r, int ud, short *lines), (ud, lines))
+GO_CALL(ibln, (go_result *r, int ud, int pad, int sad, short *listen), (ud, pad, sad, listen))
+GO_CALL(ibloc, (go_result *r, int ud), (ud))
+GO_CALL(ibonl, (go_result *r, int ud, int v), (ud, v))
+GO_CALL(ibpct, (go_result *r, int ud), (ud))
+GO_CALL(ibppc, (go_result *r, int ud, int v), (ud, v))
+GO_CALL(ibrd, (go_result *r, int ud, void *buf, size_g cnt), (ud, buf, cnt))
+GO_CALL(ibrda, (go_result *r, int ud, void *buf, size_g cnt), (ud, buf, cnt))
+GO_CALL(ibrdfA, (go_result *r, int ud, char *filename), (ud, filename))
+GO_CALL(ibrdfW, (go_result *r, int ud, go_wchar *filename), (ud, filename))
+GO_CALL(ibrpp, (go_result *r, int ud, char *ppr), (ud, ppr))
+GO_CALL(ibrsp, (go_result *r, int ud, char *spr), (ud, spr))
+GO_CALL(ibsic, (go_result *r, int ud), (ud))
+GO_CALL(ibstop, (go_result *r, int ud), (ud))
+GO_CALL(ibtrg, (go_result *r, int ud), (ud))
+GO_CALL(ibwait, (go_result *r, int ud, int mask), (ud, mask))
+GO_CALL(ibwrt, (go_result *r, int ud, void *buf, size_g cnt), (ud, buf, cnt))
+GO_CALL(ibwrta, (go_result *r, int ud, void *buf, size_g cnt), (ud, buf, cnt))
+GO_CALL(ibwrtfA, (go_result *r, int ud, char *filename), (ud, filename))
+GO_CALL(ibwrtfW, (go_result *r, int ud, go_wchar *filename), (ud, filename))
+
+// The obsolete routines below are only in ni488.h, they fail with ECAP
+// when built against ni4882.h.
+#ifdef NI488CC
+#define GO_CALL_488(name, params, args) \
+	static void go_##name params { \
+		r->sta = ERR; \
+		r->err = ECAP; \
+		r->cntl = 0; \
+	}
+#else
+#define GO_CALL_488(name, params, args) GO_CALL(name, params, args)
+#endif
+
+GO_CALL_488(ibbnaA, (go_result *r, int ud, char *udname), (ud, udname))
+GO_CALL_488(ibbnaW, (go_result *r, int ud, go_wchar *udname), (ud, udname))
+GO_CALL_488(ibdiag, (go_result *r, int ud, void *buf, size_g cnt), (ud, buf, cnt))
+GO_CALL_488(iblock, (go_result *r, int ud), (ud))
+GO_CALL_488(iblockxA, (go_result *r, int ud, int wait, char *name), (ud, wait, name))
+GO_CALL_488(iblockxW, (go_result *r, int ud, int wait, go_wchar *name), (ud, wait, name))
+GO_CALL_488(ibpoke, (go_result *r, int ud, long option, long v), (ud, option, v))
+GO_CALL_488(ibunlock, (go_result *r, int ud), (ud))
+GO_CALL_488(ibunlockx, (go_result *r, int ud), (ud))
+
+// The process-wide status comes from functions in ni4882.h and from
+// variables in ni488.h. ThreadIbcntl is a function in ni488.h and a macro
+// in ni4882.h.
+#ifdef NI488CC
+static unsigned long go_Ibsta(void) { return Ibsta(); }
+static unsigned long go_Iberr(void) { return Iberr(); }
+static unsigned long go_Ibcntl(void) { return Ibcnt(); }
+#else
+static unsigned long go_Ibsta(void) { return ibsta; }
+static unsigned long go_Iberr(void) { return iberr; }
+static unsigned long go_Ibcntl(void) { return ibcntl; }
+#endif
+static unsigned long go_ThreadIbcntl(void) { return ThreadIbcntl(); }
+
+#ifdef NI488CC
+GO_CALL(AllSpoll, (go_result *r, int boardID, short *addrlist, short *results), (boardID, addrlist, results))
+#else
+// AllSpoll is missing from the 32-bit libraries, so the devices are polled
+// one at a time with ReadStatusByte. ibcntl is the number of devices polled,
+// or on error the index of the one that failed.
+static void go_AllSpoll(go_result *r, int boardID, short *addrlist, short *results) {
+	int i;
+	r->sta = CMPL;
+	r->err = 0;
+	for (i = 0; addrlist[i] != NOADDR; i++) {
+		ReadStatusByte(boardID, addrlist[i], &results[i]);
+		r->sta = ThreadIbsta();
+		r->err = ThreadIberr();
+		if (r->sta & ERR)
+			break;
+	}
+	r->cntl = i;
+}
+#endif
+
+GO_CALL(DevClear, (go_result *r, int boardID, short addr), (boardID, addr))
+GO_CALL(DevClearList, (go_result *r, int boardID, short *addrlist), (boardID, addrlist))
+GO_CALL(EnableLocal, (go_result *r, int boardID, short *addrlist), (boardID, addrlist))
+GO_CALL(EnableRemote, (go_result *r, int boardID, short *addrlist), (boardID, addrlist))
+GO_CALL(FindLstn, (go_result *r, int boardID, short *addrlist, short *results, size_g limit), (boardID, addrlist, results, limit))
+GO_CALL(FindRQS, (go_result *r, int boardID, short *addrlist, short *status), (boardID, addrlist, status))
+GO_CALL(PPoll, (go_result *r, int boardID, short *result), (boardID, result))
+GO_CALL(PPollConfig, (go_result *r, int boardID, short addr, int dataLine, int lineSense), (boardID, addr, dataLine, lineSense))
+GO_CALL(PPollUnconfig, (go_result *r, int boardID, short *addrlist), (boardID, addrlist))
+GO_CALL(PassControl, (go_result *r, int boardID, short addr), (boardID, addr))
+GO_CALL(RcvRespMsg, (go_result *r, int boardID, void *buf, size_g cnt, int term), (boardID, buf, cnt, term))
+GO_CALL(ReadStatusByte, (go_result *r, int boardID, short addr, short *result), (boardID, addr, result))
+GO_CALL(Receive, (go_result *r, int boardID, short addr, void *buf, size_g cnt, int term), (boardID, addr, buf, cnt, term))
+GO_CALL(ReceiveSetup, (go_result *r, int boardID, short addr), (boardID, addr))
+GO_CALL(ResetSys, (go_result *r, int boardID, short *addrlist), (boardID, addrlist))
+GO_CALL(Send, (go_result *r, int boardID, short addr, void *buf, size_g cnt, int eotMode), (boardID, addr, buf, cnt, eotMode))
+GO_CALL(SendCmds, (go_result *r, int boardID, void *buf, size_g cnt), (boardID, buf, cnt))
+GO_CALL(SendDataBytes, (go_result *r, int boardID, void *buf, size_g cnt, int eotMode), (boardID, buf, cnt, eotMode))
+GO_CALL(SendIFC, (go_result *r, int boardID), (boardID))
+GO_CALL(SendLLO, (go_result *r, int boardID), (boardID))
+GO_CALL(SendList, (go_result *r, int boardID, short *addrlist, void *buf, size_g cnt, int eotMode), (boardID, addrlist, buf, cnt, eotMode))
+GO_CALL(SendSetup, (go_result *r, int boardID, short *addrlist), (boardID, addrlist))
+GO_CALL(SetRWLS, (go_result *r, int boardID, short *addrlist), (boardID, addrlist))
+GO_CALL(TestSRQ, (go_result *r, int boardID, short *result), (boardID, result))
+GO_CALL(TestSys, (go_result *r, int boardID, short *addrlist, short *results), (boardID, addrlist, results))
+GO_CALL(Trigger, (go_result *r, int boardID, short addr), (boardID, addr))
+GO_CALL(TriggerList, (go_result *r, int boardID, short *addrlist), (boardID, addrlist))
+GO_CALL(WaitSRQ, (go_result *r, int boardID, short *result), (boardID, result))
+
+// go_notify is the ibnotify callback. It hands the event to goNotify along
+// with the handle of the Go callback, passed to ibnotify as RefData.
+extern int goNotify(int ud, unsigned long sta, unsigned long err, unsigned long cntl, uintptr_t h);
+
+#ifdef NI488CC
+static int NI488CC go_notify(int ud, unsigned long sta, unsigned long err, unsigned long cntl, void *ref) {
+	return goNotify(ud, sta, err, cntl, (uintptr_t)ref);
+}
+#else
+static int __stdcall go_notify(int ud, int sta, int err, long cntl, void *ref) {
+	return goNotify(ud, (unsigned long)sta, (unsigned long)err, (unsigned long)cntl, (uintptr_t)ref);
+}
+#endif
+
+static void go_ibnotify(go_result *r, int ud, int mask, uintptr_t h) {
+	ibnotify(ud, mask, mask != 0 ? go_notify : NULL, (void *)h);
+	r->sta = ThreadIbsta();
+	r->err = ThreadIberr();
+	r->cntl = ThreadIbcnt();
+}
+
+// ibdev and ibfind return a descriptor rather than ibsta.
+static int go_ibdev(go_result *r, int boardID, int pad, int sad, int tmo, int eot, int eos) {
+	int ud = ibdev(boardID, pad, sad, tmo, eot, eos);
+	r->sta = ThreadIbsta();
+	r->err = ThreadIberr();
+	r->cntl = ThreadIbcnt();
+	return ud;
+}
+
+static int go_ibfind(go_result *r, char *udname) {
+	int ud = ibfindA(udname);
+	r->sta = ThreadIbsta();
+	r->err = ThreadIberr();
+	r->cntl = ThreadIbcnt();
+	return ud;
+}
+
+static int go_ibfindW(go_result *r, go_wchar *udname) {
+	int ud = ibfindW(udname);
+	r->sta = ThreadIbsta();
+	r->err = ThreadIberr();
+	r->cntl = ThreadIbcnt();
+	return ud;
+}
+*/
+import "C"
+import (
+	"runtime"
+	"sync"
+	"unicode/utf16"
+	"unsafe"
+)
+
+func init() {
+	SetDriver(cgoDriver{})
+}
+
+// cgoDriver is the Driver backed by the NI-488.2 C library.
+type cgoDriver struct{}
+
+// results recycles the go_result of call, which would otherwise be heap
+// allocated by every call as it is passed to f.
+var results = sync.Pool{New: func() interface{} { return new(C.go_result) }}
+
+// call makes the cgo call in f with the goroutine locked to its OS thread
+// and returns the status f captured.
+func call(f func(r *C.go_result)) Result {
+	r := results.Get().(*C.go_result)
+	defer results.Put(r)
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	f(r)
+	return Result{Status(r.sta), ErrorCode(r.err), int(r.cntl)}
+}
+
+// bufPtr returns a pointer to the first byte of b, or nil if b is empty.
+//
+// The synchronous calls pass Go memory straight to the driver, which the
+// cgo pointer rules allow as it holds no Go pointers and the driver is
+// done with it once the call returns. The asynchronous ones copy it to C
+// memory, which the driver keeps using after they return.
+func bufPtr(b []byte) unsafe.Pointer {
+	if len(b) == 0 {
+		return nil
+	}
+	return unsafe.Pointer(&b[0])
+}
+
+// isASCII reports whether s can be passed to the A variant of a routine
+// taking a name, the W variant is used otherwise.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// wideString returns s as a NUL terminated wide string in C memory, which
+// the caller must free.
+func wideString(s string) *C.go_wchar {
+	var w []C.go_wchar
+	if C.sizeof_go_wchar == 2 {
+		for _, c := range utf16.Encode([]rune(s)) {
+			w = append(w, C.go_wchar(c))
+		}
+	} else {
+		for _, c := range s {
+			w = append(w, C.go_wchar(c))
+		}
+	}
+	w = append(w, 0)
+	p := (*C.go_wchar)(C.malloc(C.size_t(len(w)) * C.sizeof_go_wchar))
+	copy(unsafe.Slice(p, len(w)), w)
+	return p
+}
+
+// addrList returns a NOADDR terminated copy of addrlist.
+func addrList(addrlist []Address) *C.short {
+	n := make([]C.short, len(addrlist)+1)
+	for i, a := range addrlist {
+		n[i] = C.short(a)
+	}
+	n[len(addrlist)] = C.short(NOADDR)
+	return &n[0]
+}
+
+//  NI-488 Functions
+
+func (cgoDriver) Ibask(ud int, option ConfigOption) (int, Result) {
+	var v C.int
+	r := call(func(r *C.go_result) {
+		C.go_ibask(r, C.int(ud), C.int(option), &v)
+	})
+	return int(v), r
+}
+
+func (cgoDriver) Ibbna(ud int, udname string) Result {
+	if !isASCII(udname) {
+		n := wideString(udname)
+		defer C.free(unsafe.Pointer(n))
+		return call(func(r *C.go_result) { C.go_ibbnaW(r, C.int(ud), n) })
+	}
+	n := C.CString(udname)
+	defer C.free(unsafe.Pointer(n))
+	return call(func(r *C.go_result) { C.go_ibbnaA(r, C.int(ud), n) })
+}
+
+func (cgoDriver) Ibcac(ud, v int) Result {
+	return call(func(r *C.go_result) { C.go_ibcac(r, C.int(ud), C.int(v)) })
+}
+
+func (cgoDriver) Ibclr(ud int) Result {
+	return call(func(r *C.go_result) { C.go_ibclr(r, C.int(ud)) })
+}
+
+func (cgoDriver) Ibcmd(ud int, cmds []byte) Result {
+	return call(func(r *C.go_result) {
+		C.go_ibcmd(r, C.int(ud), bufPtr(cmds), C.size_g(len(cmds)))
+	})
+}
+
+func (cgoDriver) Ibcmda(ud int, cmds []byte) Result {
+	n := C.CBytes(cmds)
+	r := call(func(r *C.go_result) {
+		C.go_ibcmda(r, C.int(ud), n, C.size_g(len(cmds)))
+	})
+	return pending.start(ud, n, nil, r)
+}
+
+func (cgoDriver) Ibconfig(ud int, option ConfigOption, v int) Result {
+	return call(func(r *C.go_result) {
+		C.go_ibconfig(r, C.int(ud), C.int(option), C.int(v))
+	})
+}
+
+func (cgoDriver) Ibdev(boardID, pad, sad int, tmo Timeout, eot, eos int) (int, Result) {
+	var ud C.int
+	r := call(func(r *C.go_result) {
+		ud = C.go_ibdev(r, C.int(boardID), C.int(pad), C.int(sad),
+			C.int(tmo), C.int(eot), C.int(eos))
+	})
+	return int(ud), r
+}
+
+func (cgoDriver) Ibdiag(ud int, buf []byte) Result {
+	return call(func(r *C.go_result) {
+		C.go_ibdiag(r, C.int(ud), bufPtr(buf), C.size_g(len(buf)))
+	})
+}
+
+func (cgoDriver) Ibeos(ud, v int) Result {
+	return call(func(r *C.go_result) { C.go_ibeos(r, C.int(ud), C.int(v)) })
+}
+
+func (cgoDriver) Ibexpert(ud, option int, input, output []byte) Result {
+	return call(func(r *C.go_result) {
+		C.go_ibexpert(r, C.int(ud), C.int(option), bufPtr(input), bufPtr(output))
+	})
+}
+
+func (cgoDriver) Ibfind(udname string) (int, Result) {
+	var ud C.int
+	if !isASCII(udname) {
+		n := wideString(udname)
+		defer C.free(unsafe.Pointer(n))
+		r := call(func(r *C.go_result) { ud = C.go_ibfindW(r, n) })
+		return int(ud), r
+	}
+	n := C.CString(udname)
+	defer C.free(unsafe.Pointer(n))
+	r := call(func(r *C.go_result) { ud = C.go_ibfind(r, n) })
+	return int(ud), r
+}
+
+func (cgoDriver) Ibgts(ud, v int) Result {
+	return call(func(r *C.go_result) { C.go_ibgts(r, C.int(ud), C.int(v)) })
+}
+
+func (cgoDriver) Iblck(ud, v int, lockWaitTime uint) Result {
+	return call(func(r *C.go_result) {
+		C.go_iblck(r, C.int(ud), C.int(v), C.uint(lockWaitTime))
+	})
+}
+
+func (cgoDriver) Iblines(ud int) (int16, Result) {
+	var lines C.short
+	r := call(func(r *C.go_result) { C.go_iblines(r, C.int(ud), &lines) })
+	return int16(lines), r
+}
+
+func (cgoDriver) Ibln(ud, pad, sad int) (int16, Result) {
+	var listen C.short
+	r := call(func(r *C.go_result) {
+		C.go_ibln(r, C.int(ud), C.int(pad), C.int(sad), &listen)
+	})
+	return int16(listen), r
+}
+
+func (cgoDriver) Ibloc(ud int) Result {
+	return call(func(r *C.go_result) { C.go_ibloc(r, C.int(ud)) })
+}
+
+func (cgoDriver) Iblock(ud int) Result {
+	return call(func(r *C.go_result) { C.go_iblock(r, C.int(ud)) })
+}
+
+func (cgoDriver) Iblockx(ud, lockWaitTime int, lockShareName string) Result {
+	if !isASCII(lockShareName) {
+		n := wideString(lockShareName)
+		defer C.free(unsafe.Pointer(n))
+		return call(func(r *C.go_result) {
+			C.go_iblockxW(r, C.int(ud), C.int(lockWaitTime), n)
+		})
+	}
+	n := C.CString(lockShareName)
+	defer C.free(unsafe.Pointer(n))
+	return call(func(r *C.go_result) {
+		C.go_iblockxA(r, C.int(ud), C.int(lockWaitTime), n)
+	})
+}
+
+func (cgoDriver) Ibnotify(ud int, mask Status, f NotifyFunc) Result {
+	var h uintptr
+	if mask != 0 {
+		h = notifiers.add(ud, f)
+	}
+	r := call(func(r *C.go_result) {
+		C.go_ibnotify(r, C.int(ud), C.int(mask), C.uintptr_t(h))
+	})
+	if r.Ibsta&ERR != 0 {
+		notifiers.remove(h)
+	} else {
+		notifiers.install(ud, h)
+	}
+	return r
+}
+
+func (cgoDriver) Ibonl(ud, v int) Result {
+	r := call(func(r *C.go_result) { C.go_ibonl(r, C.int(ud), C.int(v)) })
+	pending.finish(ud, r.Ibcntl)
+	return r
+}
+
+func (cgoDriver) Ibpct(ud int) Result {
+	return call(func(r *C.go_result) { C.go_ibpct(r, C.int(ud)) })
+}
+
+func (cgoDriver) Ibpoke(ud, option, v int) Result {
+	return call(func(r *C.go_result) {
+		C.go_ibpoke(r, C.int(ud), C.long(option), C.long(v))
+	})
+}
+
+func (cgoDriver) Ibppc(ud, v int) Result {
+	return call(func(r *C.go_result) { C.go_ibppc(r, C.int(ud), C.int(v)) })
+}
+
+func (cgoDriver) Ibrd(ud int, buf []byte) Result {
+	return call(func(r *C.go_result) {
+		C.go_ibrd(r, C.int(ud), bufPtr(buf), C.size_g(len(buf)))
+	})
+}
+
+func (cgoDriver) Ibrda(ud int, buf []byte) Result {
+	n := C.malloc(C.size_t(len(buf) + 1))
+	r := call(func(r *C.go_result) {
+		C.go_ibrda(r, C.int(ud), n, C.size_g(len(buf)))
+	})
+	return pending.start(ud, n, buf, r)
+}
+
+func (cgoDriver) Ibrdf(ud int, filename string) Result {
+	if !isASCII(filename) {
+		n := wideString(filename)
+		defer C.free(unsafe.Pointer(n))
+		return call(func(r *C.go_result) { C.go_ibrdfW(r, C.int(ud), n) })
+	}
+	n := C.CString(filename)
+	defer C.free(unsafe.Pointer(n))
+	return call(func(r *C.go_result) { C.go_ibrdfA(r, C.int(ud), n) })
+}
+
+func (cgoDriver) Ibrpp(ud int) (byte, Result) {
+	var ppr C.char
+	r := call(func(r *C.go_result) { C.go_ibrpp(r, C.int(ud), &ppr) })
+	return byte(ppr), r
+}
+
+func (cgoDriver) Ibrsp(ud int) (byte, Result) {
+	var spr C.char
+	r := call(func(r *C.go_result) { C.go_ibrsp(r, C.int(ud), &spr) })
+	return byte(spr), r
+}
+
+func (cgoDriver) Ibsic(ud int) Result {
+	return call(func(r *C.go_result) { C.go_ibsic(r, C.int(ud)) })
+}
+
+func (cgoDriver) Ibstop(ud int) Result {
+	r := call(func(r *C.go_result) { C.go_ibstop(r, C.int(ud)) })
+	pending.finish(ud, r.Ibcntl)
+	return r
+}
+
+func (cgoDriver) Ibtrg(ud int) Result {
+	return call(func(r *C.go_result) { C.go_ibtrg(r, C.int(ud)) })
+}
+
+func (cgoDriver) Ibunlock(ud int) Result {
+	return call(func(r *C.go_result) { C.go_ibunlock(r, C.int(ud)) })
+}
+
+func (cgoDriver) Ibunlockx(ud int) Result {
+	return call(func(r *C.go_result) { C.go_ibunlockx(r, C.int(ud)) })
+}
+
+func (cgoDriver) Ibwait(ud int, mask Status) Result {
+	r := call(func(r *C.go_result) { C.go_ibwait(r, C.int(ud), C.int(mask)) })
+	if r.Ibsta&CMPL != 0 {
+		pending.finish(ud, r.Ibcntl)
+	}
+	return r
+}
+
+func (cgoDriver) Ibwrt(ud int, buf []byte) Result {
+	return call(func(r *C.go_result) {
+		C.go_ibwrt(r, C.int(ud), bufPtr(buf), C.size_g(len(buf)))
+	})
+}
+
+func (cgoDriver) Ibwrta(ud int, buf []byte) Result {
+	n := C.CBytes(buf)
+	r := call(func(r *C.go_result) {
+		C.go_ibwrta(r, C.int(ud), n, C.size_g(len(buf)))
+	})
+	return pending.start(ud, n, nil, r)
+}
+
+func (cgoDriver) Ibwrtf(ud int, filename string) Result {
+	if !isASCII(filename) {
+		n := wideString(filename)
+		defer C.free(unsafe.Pointer(n))
+		return call(func(r *C.go_result) { C.go_ibwrtfW(r, C.int(ud), n) })
+	}
+	n := C.CString(filename)
+	defer C.free(unsafe.Pointer(n))
+	return call(func(r *C.go_result) { C.go_ibwrtfA(r, C.int(ud), n) })
+}
+
+func (cgoDriver) Ibsta() uint32 {
+	return uint32(C.go_Ibsta())
+}
+
+func (cgoDriver) Iberr() uint32 {
+	return uint32(C.go_Iberr())
+}
+
+func (cgoDriver) Ibcntl() uint32 {
+	return uint32(C.go_Ibcntl())
+}
+
+func (cgoDriver) ThreadIbsta() uint32 {
+	return uint32(C.ThreadIbsta())
+}
+
+func (cgoDriver) ThreadIberr() uint32 {
+	return uint32(C.ThreadIberr())
+}
+
+func (cgoDriver) ThreadIbcntl() uint32 {
+	return uint32(C.go_ThreadIbcntl())
+}
+
+//  NI-488.2 Functions
+
+func (cgoDriver) AllSpoll(boardID int, addrlist []Address, results []int16) Result {
+	res := make([]C.short, len(results)+1)
+	r := call(func(r *C.go_result) {
+		C.go_AllSpoll(r, C.int(boardID), addrList(addrlist), &res[0])
+	})
+	for i := range results {
+		results[i] = int16(res[i])
+	}
+	return r
+}
+
+func (cgoDriver) DevClear(boardID int, addr Address) Result {
+	return call(func(r *C.go_result) {
+		C.go_DevClear(r, C.int(boardID), C.short(addr))
+	})
+}
+
+func (cgoDriver) DevClearList(boardID int, addrlist []Address) Result {
+	return call(func(r *C.go_result) {
+		C.go_DevClearList(r, C.int(boardID), addrList(addrlist))
+	})
+}
+
+func (cgoDriver) EnableLocal(boardID int, addrlist []Address) Result {
+	return call(func(r *C.go_result) {
+		C.go_EnableLocal(r, C.int(boardID), addrList(addrlist))
+	})
+}
+
+func (cgoDriver) EnableRemote(boardID int, addrlist []Address) Result {
+	return call(func(r *C.go_result) {
+		C.go_EnableRemote(r, C.int(boardID), addrList(addrlist))
+	})
+}
+
+func (cgoDriver) FindLstn(boardID int, addrlist, results []Address) Result {
+	res := make([]C.short, len(results)+1)
+	r := call(func(r *C.go_result) {
+		C.go_FindLstn(r, C.int(boardID), addrList(addrlist), &res[0],
+			C.size_g(len(results)))
+	})
+	for i := range results {
+		results[i] = Address(res[i])
+	}
+	return r
+}
+
+func (cgoDriver) FindRQS(boardID int, addrlist []Address) (int16, Result) {
+	var status C.short
+	r := call(func(r *C.go_result) {
+		C.go_FindRQS(r, C.int(boardID), addrList(addrlist), &status)
+	})
+	return int16(status), r
+}
+
+func (cgoDriver) PPoll(boardID int) (int16, Result) {
+	var result C.short
+	r := call(func(r *C.go_result) { C.go_PPoll(r, C.int(boardID), &result) })
+	return int16(result), r
+}
+
+func (cgoDriver) PPollConfig(boardID int, addr Address, dataLine, lineSense int) Result {
+	return call(func(r *C.go_result) {
+		C.go_PPollConfig(r, C.int(boardID), C.short(addr),
+			C.int(dataLine), C.int(lineSense))
+	})
+}
+
+func (cgoDriver) PPollUnconfig(boardID int, addrlist []Address) Result {
+	return call(func(r *C.go_result) {
+		C.go_PPollUnconfig(r, C.int(boardID), addrList(addrlist))
+	})
+}
+
+func (cgoDriver) PassControl(boardID int, addr Address) Result {
+	return call(func(r *C.go_result) {
+		C.go_PassControl(r, C.int(boardID), C.short(addr))
+	})
+}
+
+func (cgoDriver) RcvRespMsg(boardID int, buf []byte, termination int) Result {
+	return call(func(r *C.go_result) {
+		C.go_RcvRespMsg(r, C.int(boardID), bufPtr(buf), C.size_g(len(buf)),
+			C.int(termination))
+	})
+}
+
+func (cgoDriver) ReadStatusByte(boardID int, addr Address) (int16, Result) {
+	var result C.short
+	r := call(func(r *C.go_result) {
+		C.go_ReadStatusByte(r, C.int(boardID), C.short(addr), &result)
+	})
+	return int16(result), r
+}
+
+func (cgoDriver) Receive(boardID int, addr Address, buf []byte, termination int) Result {
+	return call(func(r *C.go_result) {
+		C.go_Receive(r, C.int(boardID), C.short(addr), bufPtr(buf),
+			C.size_g(len(buf)), C.int(termination))
+	})
+}
+
+func (cgoDriver) ReceiveSetup(boardID int, addr Address) Result {
+	return call(func(r *C.go_result) {
+		C.go_ReceiveSetup(r, C.int(boardID), C.short(addr))
+	})
+}
+
+func (cgoDriver) ResetSys(boardID int, addrlist []Address) Result {
+	return call(func(r *C.go_result) {
+		C.go_ResetSys(r, C.int(boardID), addrList(addrlist))
+	})
+}
+
+func (cgoDriver) Send(boardID int, addr Address, data []byte, eotMode int) Result {
+	return call(func(r *C.go_result) {
+		C.go_Send(r, C.int(boardID), C.short(addr), bufPtr(data), C.size_g(len(data)),
+			C.int(eotMode))
+	})
+}
+
+func (cgoDriver) SendCmds(boardID int, cmds []byte) Result {
+	return call(func(r *C.go_result) {
+		C.go_SendCmds(r, C.int(boardID), bufPtr(cmds), C.size_g(len(cmds)))
+	})
+}
+
+func (cgoDriver) SendDataBytes(boardID int, data []byte, eotMode int) Result {
+	return call(func(r *C.go_result) {
+		C.go_SendDataBytes(r, C.int(boardID), bufPtr(data), C.size_g(len(data)),
+			C.int(eotMode))
+	})
+}
+
+func (cgoDriver) SendIFC(boardID int) Result {
+	return call(func(r *C.go_result) { C.go_SendIFC(r, C.int(boardID)) })
+}
+
+func (cgoDriver) SendLLO(boardID int) Result {
+	return call(func(r *C.go_result) { C.go_SendLLO(r, C.int(boardID)) })
+}
+
+func (cgoDriver) SendList(boardID int, addrlist []Address, data []byte, eotMode int) Result {
+	return call(func(r *C.go_result) {
+		C.go_SendList(r, C.int(boardID), addrList(addrlist), bufPtr(data),
+			C.size_g(len(data)), C.int(eotMode))
+	})
+}
+
+func (cgoDriver) SendSetup(boardID int, addrlist []Address) Result {
+	return call(func(r *C.go_result) {
+		C.go_SendSetup(r, C.int(boardID), addrList(addrlist))
+	})
+}
+
+func (cgoDriver) SetRWLS(boardID int, addrlist []Address) Result {
+	return call(func(r *C.go_result) {
+		C.go_SetRWLS(r, C.int(boardID), addrList(addrlist))
+	})
+}
+
+func (cgoDriver) TestSRQ(boardID int) (int16, Result) {
+	var result C.short
+	r := call(func(r *C.go_result) { C.go_TestSRQ(r, C.int(boardID), &result) })
+	return int16(result), r
+}
+
+func (cgoDriver) TestSys(boardID int, addrlist []Address, results []int16) Result {
+	res := make([]C.short, len(results)+1)
+	r := call(func(r *C.go_result) {
+		C.go_TestSys(r, C.int(boardID), addrList(addrlist), &res[0])
+	})
+	for i := range results {
+		results[i] = int16(res[i])
+	}
+	return r
+}
+
+func (cgoDriver) Trigger(boardID int, addr Address) Result {
+	return call(func(r *C.go_result) {
+		C.go_Trigger(r, C.int(boardID), C.short(addr))
+	})
+}
+
+func (cgoDriver) TriggerList(boardID int, addrlist []Address) Result {
+	return call(func(r *C.go_result) {
+		C.go_TriggerList(r, C.int(boardID), addrList(addrlist))
+	})
+}
+
+func (cgoDriver) WaitSRQ(boardID int) (int16, Result) {
+	var result C.short
+	r := call(func(r *C.go_result) { C.go_WaitSRQ(r, C.int(boardID), &result) })
+	return int16(result), r
+}
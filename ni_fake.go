@@ -0,0 +1,136 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+//go:build fakegpib && amd64 && !nogpib
+// +build fakegpib,amd64,!nogpib
+
+package ni488
+
+/*
+#include <stdlib.h>
+
+extern void fake_reset(void);
+extern void fake_set_result(const char *fn, unsigned long sta, unsigned long err, unsigned long cntl);
+extern void fake_set_output(const char *fn, const short *vals, int n);
+extern void fake_set_read(const void *data, size_t n);
+extern int fake_ncalls(void);
+extern int fake_call_info(int i, char *fn, long *args, char *data, size_t *ndata, short *list, int *nlist);
+extern int fake_notify(unsigned long sta, unsigned long err, unsigned long cntl, int *mask);
+*/
+import "C"
+import "unsafe"
+
+// Sizes of the call records kept by fakegpib.c.
+const (
+	fakeNameLen = 16
+	fakeMaxArgs = 6
+	fakeMaxData = 1024
+	fakeMaxList = 32
+)
+
+// FakeCall is a call recorded by the fake NI-488.2 library, which stands
+// in for libgpibapi in builds tagged fakegpib.
+type FakeCall struct {
+	// Func is the C function name, e.g. "ibwrt" or "SendList".
+	Func string
+
+	// Args holds the integer arguments in declaration order. Buffer
+	// arguments are represented by their count.
+	Args []int
+
+	// Data holds the bytes of a buffer or file name argument, at most
+	// 1024 of them.
+	Data []byte
+
+	// List holds an address list argument without its NOADDR.
+	List []Address
+}
+
+// FakeReset clears the recorded calls and everything scripted.
+func FakeReset() {
+	C.fake_reset()
+}
+
+// FakeCalls returns the calls made since the last FakeReset, at most 256.
+func FakeCalls() []FakeCall {
+	n := int(C.fake_ncalls())
+	calls := make([]FakeCall, 0, n)
+	for i := 0; i < n; i++ {
+		var (
+			fn    [fakeNameLen]C.char
+			args  [fakeMaxArgs]C.long
+			data  [fakeMaxData]C.char
+			ndata C.size_t
+			list  [fakeMaxList]C.short
+			nlist C.int
+		)
+		nargs := int(C.fake_call_info(C.int(i), &fn[0], &args[0], &data[0], &ndata, &list[0], &nlist))
+		if nargs < 0 {
+			break
+		}
+		c := FakeCall{Func: C.GoString(&fn[0])}
+		for _, a := range args[:nargs] {
+			c.Args = append(c.Args, int(a))
+		}
+		if ndata > fakeMaxData {
+			ndata = fakeMaxData
+		}
+		if ndata > 0 {
+			c.Data = C.GoBytes(unsafe.Pointer(&data[0]), C.int(ndata))
+		}
+		for _, a := range list[:nlist] {
+			c.List = append(c.List, Address(a))
+		}
+		calls = append(calls, c)
+	}
+	return calls
+}
+
+// FakeSetResult makes every later call to fn set ibsta, iberr and ibcntl
+// to the given values. Without it a call completes with CMPL and the
+// number of bytes transferred.
+func FakeSetResult(fn string, ibsta Status, iberr ErrorCode, ibcntl uint32) {
+	cs := C.CString(fn)
+	defer C.free(unsafe.Pointer(cs))
+	C.fake_set_result(cs, C.ulong(ibsta), C.ulong(iberr), C.ulong(ibcntl))
+}
+
+// FakeSetOutput sets the values fn stores through its output parameters:
+// the ud returned by ibdev and ibfindA, the value of ibask, iblines and
+// ibln, the byte of ibrpp and ibrsp, the result of PPoll, TestSRQ,
+// WaitSRQ, FindRQS and ReadStatusByte, or the result list of FindLstn,
+// AllSpoll and TestSys.
+func FakeSetOutput(fn string, vals ...int16) {
+	if len(vals) > fakeMaxList {
+		vals = vals[:fakeMaxList]
+	}
+	cvals := make([]C.short, len(vals)+1)
+	for i, v := range vals {
+		cvals[i] = C.short(v)
+	}
+	cs := C.CString(fn)
+	defer C.free(unsafe.Pointer(cs))
+	C.fake_set_output(cs, &cvals[0], C.int(len(vals)))
+}
+
+// FakeSetRead sets the data returned by ibrd, ibrda, RcvRespMsg and
+// Receive. Reads consume it in order and set END with its last byte.
+func FakeSetRead(data []byte) {
+	if len(data) == 0 {
+		C.fake_set_read(nil, 0)
+		return
+	}
+	C.fake_set_read(unsafe.Pointer(&data[0]), C.size_t(len(data)))
+}
+
+// FakeNotify invokes the callback last installed with ibnotify as the
+// driver would on an event, and returns the mask the callback re-armed
+// with. It returns false if no callback is installed.
+func FakeNotify(ibsta Status, iberr ErrorCode, ibcntl uint32) (mask Status, ok bool) {
+	var m C.int
+	if C.fake_notify(C.ulong(ibsta), C.ulong(iberr), C.ulong(ibcntl), &m) == 0 {
+		return 0, false
+	}
+	return Status(m), true
+}
@@ -0,0 +1,271 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+//go:build fakegpib && amd64 && !nogpib
+// +build fakegpib,amd64,!nogpib
+
+package ni488
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// useFake installs the cgo driver, linked against fakegpib.c, for the
+// rest of the test.
+func useFake(t *testing.T) {
+	prev := SetDriver(cgoDriver{})
+	FakeReset()
+	t.Cleanup(func() {
+		FakeReset()
+		SetDriver(prev)
+	})
+}
+
+// lastCall returns the last call recorded by the fake library.
+func lastCall(t *testing.T) FakeCall {
+	calls := FakeCalls()
+	if len(calls) == 0 {
+		t.Fatal("no call recorded")
+	}
+	return calls[len(calls)-1]
+}
+
+func TestFakeStatusBytes(t *testing.T) {
+	useFake(t)
+	// The C char output parameters hold bytes with the high bit set,
+	// which must not be sign extended.
+	FakeSetOutput("ibrsp", 0xC1)
+	if spr, r, err := Ibrsp(3); err != nil || spr != 0xC1 || r.Ibcntl != 1 {
+		t.Errorf("Ibrsp = %#x, %+v, %v; want 0xc1", spr, r, err)
+	}
+	FakeSetOutput("ibrpp", 0x81)
+	if ppr, _, err := Ibrpp(0); err != nil || ppr != 0x81 {
+		t.Errorf("Ibrpp = %#x, %v; want 0x81", ppr, err)
+	}
+	FakeSetOutput("ReadStatusByte", 0x50)
+	if sb, _, err := ReadStatusByte(0, 5); err != nil || sb != 0x50 {
+		t.Errorf("ReadStatusByte = %#x, %v; want 0x50", sb, err)
+	}
+}
+
+func TestFakeLists(t *testing.T) {
+	useFake(t)
+	addrs := []Address{1, MakeAddr(22, 0x60), 30}
+
+	FakeSetOutput("FindLstn", 1, int16(MakeAddr(22, 0x60)))
+	found, r, err := FindLstn(0, addrs, 8)
+	if err != nil || !reflect.DeepEqual(found, addrs[:2]) || r.Ibcntl != 2 {
+		t.Errorf("FindLstn = %v, %+v, %v; want %v", found, r, err, addrs[:2])
+	}
+	if c := lastCall(t); !reflect.DeepEqual(c.List, addrs) || c.Args[1] != 8 {
+		t.Errorf("FindLstn call %+v, want list %v and limit 8", c, addrs)
+	}
+
+	FakeSetOutput("AllSpoll", 0x40, 0x01, 0x00)
+	spr, _, err := AllSpoll(0, addrs)
+	if err != nil || !reflect.DeepEqual(spr, []int16{0x40, 0x01, 0x00}) {
+		t.Errorf("AllSpoll = %v, %v", spr, err)
+	}
+
+	FakeSetOutput("TestSys", 0, 7, 0)
+	codes, r, err := TestSys(0, addrs)
+	if err != nil || !reflect.DeepEqual(codes, []int16{0, 7, 0}) || r.Ibcntl != 1 {
+		t.Errorf("TestSys = %v, %+v, %v", codes, r, err)
+	}
+
+	// The lists handed to C end with NOADDR, an empty one is only that.
+	for _, addrs := range [][]Address{nil, {5}, addrs} {
+		if _, err := DevClearList(0, addrs); err != nil {
+			t.Fatal(err)
+		}
+		if c := lastCall(t); len(c.List) != len(addrs) || len(addrs) > 0 && !reflect.DeepEqual(c.List, addrs) {
+			t.Errorf("DevClearList(%v) passed %v", addrs, c.List)
+		}
+	}
+}
+
+func TestFakeWriteArgs(t *testing.T) {
+	useFake(t)
+	if r, err := Ibwrt(3, "*IDN?\n"); err != nil || r.Ibcntl != 6 {
+		t.Fatalf("Ibwrt = %+v, %v", r, err)
+	}
+	c := lastCall(t)
+	if c.Func != "ibwrt" || !reflect.DeepEqual(c.Args, []int{3, 6}) || string(c.Data) != "*IDN?\n" {
+		t.Errorf("ibwrt call %+v", c)
+	}
+
+	data := []byte("VOLT 1.5\n")
+	n, _, err := SendBuf(0, NLend, MakeAddr(22, 0x61), data)
+	if err != nil || n != len(data) {
+		t.Fatalf("SendBuf = %d, %v", n, err)
+	}
+	c = lastCall(t)
+	want := []int{0, int(MakeAddr(22, 0x61)), len(data), NLend}
+	if c.Func != "Send" || !reflect.DeepEqual(c.Args, want) || !bytes.Equal(c.Data, data) {
+		t.Errorf("Send call %+v, want args %v", c, want)
+	}
+}
+
+func TestFakeResult(t *testing.T) {
+	useFake(t)
+	FakeSetResult("ibclr", ERR, EDVR, 2)
+	_, err := Ibclr(9)
+	e, ok := err.(*Error)
+	if !ok || e.Op != "ibclr" || e.Ud != 9 || e.Code != EDVR || e.Count != 2 {
+		t.Errorf("Ibclr error %#v", err)
+	}
+	if ThreadIberr() != EDVR || ThreadIbcntl() != 2 {
+		t.Errorf("thread iberr %v ibcntl %d", ThreadIberr(), ThreadIbcntl())
+	}
+}
+
+// TestFakeResultThread checks that each Result is the status of its own
+// call, with two calls scripted to fail differently made over and over
+// from goroutines locked to their threads, where each call overwrites the
+// thread status of the one before.
+func TestFakeResultThread(t *testing.T) {
+	useFake(t)
+	want := map[string]Result{
+		"ibclr": {ERR | CMPL, EARG, 3},
+		"ibtrg": {ERR | TIMO | CMPL, EABO, 7},
+	}
+	for fn, r := range want {
+		FakeSetResult(fn, r.Ibsta, r.Iberr, uint32(r.Ibcntl))
+	}
+	var d cgoDriver
+	calls := map[string]func() Result{
+		"ibclr": func() Result { return d.Ibclr(1) },
+		"ibtrg": func() Result { return d.Ibtrg(2) },
+	}
+	errs := make(chan error, 2)
+	for g := 0; g < 2; g++ {
+		go func(g int) {
+			runtime.LockOSThread()
+			defer runtime.UnlockOSThread()
+			order := []string{"ibclr", "ibtrg"}
+			for i := 0; i < 500; i++ {
+				if (i+g)%2 == 1 {
+					order[0], order[1] = order[1], order[0]
+				}
+				for _, fn := range order {
+					if r := calls[fn](); r != want[fn] {
+						errs <- fmt.Errorf("%s = %+v, want %+v", fn, r, want[fn])
+						return
+					}
+				}
+			}
+			errs <- nil
+		}(g)
+	}
+	for g := 0; g < 2; g++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestFakeReadAsync(t *testing.T) {
+	useFake(t)
+	FakeSetRead([]byte("hello"))
+	FakeSetResult("ibrda", 0, 0, 0) // still in progress
+	buf := make([]byte, 16)
+	if _, err := Ibrda(4, buf); err != nil {
+		t.Fatal(err)
+	}
+	// The driver reads into memory of its own, buf is only filled in
+	// once Ibwait reports the read complete.
+	if buf[0] != 0 {
+		t.Fatalf("buf %q filled before the read completed", buf)
+	}
+	FakeSetResult("ibwait", TIMO, 0, 0)
+	Ibwait(4, TIMO|CMPL)
+	if buf[0] != 0 {
+		t.Fatalf("buf %q filled on TIMO", buf)
+	}
+	FakeSetResult("ibwait", CMPL|END, 0, 5)
+	if _, err := Ibwait(4, TIMO|CMPL); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf[:6]) != "hello\x00" {
+		t.Errorf("buf %q, want hello", buf)
+	}
+}
+
+func TestFakeNotify(t *testing.T) {
+	useFake(t)
+	var got []Event
+	_, err := Ibnotify(3, RQS, func(ud int, ibsta Status, iberr ErrorCode, ibcntl int) {
+		got = append(got, Event{ud, Result{ibsta, iberr, ibcntl}})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mask, ok := FakeNotify(RQS|CMPL, 0, 7)
+	if !ok || mask != RQS {
+		t.Fatalf("FakeNotify = %v, %v; want the callback to re-arm with RQS", mask, ok)
+	}
+	want := []Event{{3, Result{RQS | CMPL, 0, 7}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("callback got %+v, want %+v", got, want)
+	}
+	if _, err := Ibnotify(3, 0, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := FakeNotify(RQS, 0, 0); ok {
+		t.Error("callback still installed after a mask of 0")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := Notify(ctx, 3, SRQI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go FakeNotify(SRQI|CMPL, 0, 5)
+	if ev := <-ch; ev.Ud != 3 || ev.Ibcntl != 5 || ev.Ibsta&SRQI == 0 {
+		t.Errorf("Notify event %+v", ev)
+	}
+	cancel()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Error("event after cancel")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+	notifiers.Lock()
+	n := len(notifiers.funcs) + len(notifiers.byUd)
+	notifiers.Unlock()
+	if n != 0 {
+		t.Errorf("%d callbacks left registered", n)
+	}
+}
+
+// TestFakeWriteAllocs checks that the synchronous writes pass their buffer
+// to the driver without allocating.
+func TestFakeWriteAllocs(t *testing.T) {
+	useFake(t)
+	var d cgoDriver
+	buf := []byte("*IDN?\n")
+	tests := []struct {
+		name string
+		f    func()
+	}{
+		{"Ibwrt", func() { d.Ibwrt(3, buf) }},
+		{"Ibcmd", func() { d.Ibcmd(0, buf) }},
+		{"Send", func() { d.Send(0, 22, buf, int(NLend)) }},
+		{"SendDataBytes", func() { d.SendDataBytes(0, buf, int(NLend)) }},
+	}
+	for _, tt := range tests {
+		if n := testing.AllocsPerRun(100, tt.f); n != 0 {
+			t.Errorf("%s: %v allocations, want 0", tt.name, n)
+		}
+	}
+}
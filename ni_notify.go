@@ -0,0 +1,91 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+//go:build !nogpib
+// +build !nogpib
+
+package ni488
+
+/*
+#include <stdint.h>
+*/
+import "C"
+import "sync"
+
+// Go callbacks cannot be handed to C, so Ibnotify registers them here and
+// passes their handle as RefData instead. go_notify passes it back.
+var notifiers = notifyRegistry{
+	funcs: make(map[uintptr]notifyEntry),
+	byUd:  make(map[int]uintptr),
+}
+
+type notifyEntry struct {
+	ud int
+	f  NotifyFunc
+}
+
+type notifyRegistry struct {
+	sync.Mutex
+	next  uintptr
+	funcs map[uintptr]notifyEntry
+	byUd  map[int]uintptr // the callback installed on each descriptor
+}
+
+// add registers f for ud and returns its handle, which is never 0.
+func (n *notifyRegistry) add(ud int, f NotifyFunc) uintptr {
+	n.Lock()
+	defer n.Unlock()
+	n.next++
+	n.funcs[n.next] = notifyEntry{ud, f}
+	return n.next
+}
+
+// install records h as the callback of ud, dropping the one it replaces.
+// A handle of 0 removes the callback of ud.
+func (n *notifyRegistry) install(ud int, h uintptr) {
+	n.Lock()
+	defer n.Unlock()
+	if old, ok := n.byUd[ud]; ok && old != h {
+		delete(n.funcs, old)
+	}
+	if h == 0 {
+		delete(n.byUd, ud)
+		return
+	}
+	n.byUd[ud] = h
+}
+
+// remove drops the callback with handle h.
+func (n *notifyRegistry) remove(h uintptr) {
+	n.Lock()
+	defer n.Unlock()
+	if e, ok := n.funcs[h]; ok {
+		delete(n.funcs, h)
+		if n.byUd[e.ud] == h {
+			delete(n.byUd, e.ud)
+		}
+	}
+}
+
+func (n *notifyRegistry) lookup(h uintptr) NotifyFunc {
+	n.Lock()
+	defer n.Unlock()
+	return n.funcs[h].f
+}
+
+//export goNotify
+func goNotify(ud C.int, sta, err, cntl C.ulong, h C.uintptr_t) C.int {
+	if Status(sta)&CMPL != 0 {
+		pending.finish(int(ud), int(cntl))
+	}
+	f := notifiers.lookup(uintptr(h))
+	if f == nil {
+		return 0
+	}
+	mask := f(int(ud), Status(sta), ErrorCode(err), int(cntl))
+	if mask == 0 {
+		notifiers.remove(uintptr(h))
+	}
+	return C.int(mask)
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"context"
+	"sync"
+)
+
+// NotifyFunc is an Ibnotify callback as the driver sees it. It is called
+// with the descriptor and the ibsta, iberr and ibcntl of the event, and
+// returns the mask to re-arm with, or 0 to stop.
+type NotifyFunc func(ud int, ibsta Status, iberr ErrorCode, ibcntl int) (mask Status)
+
+// Event is a GPIB event delivered by Notify.
+type Event struct {
+	Ud int
+	Result
+}
+
+// Notify installs an Ibnotify callback for the events in mask, e.g.
+// SRQI, RQS, CMPL or TIMO, and delivers every event on the returned
+// channel. The callback re-arms after each event until ctx is done; then
+// the callback is removed and the channel closed.
+//
+// The driver waits for each event to be received before reporting the
+// next, so the channel must be drained. A descriptor has a single
+// callback, installing another one on ud, with Notify or Ibnotify, stops
+// this one, and ctx ending then leaves the other one installed.
+func Notify(ctx context.Context, ud int, mask Status) (<-chan Event, error) {
+	var (
+		mu     sync.RWMutex
+		closed bool
+	)
+	ch := make(chan Event, 1)
+	f := func(ud int, ibsta Status, iberr ErrorCode, ibcntl int) Status {
+		mu.RLock()
+		defer mu.RUnlock()
+		if closed {
+			return 0
+		}
+		select {
+		case ch <- Event{ud, Result{ibsta, iberr, ibcntl}}:
+			return mask
+		case <-ctx.Done():
+			return 0
+		}
+	}
+	r, gen := callbacks.install(ud, mask, f)
+	if _, err := check("ibnotify", ud, r); err != nil {
+		return nil, err
+	}
+	go func() {
+		<-ctx.Done()
+		callbacks.remove(ud, gen)
+		mu.Lock()
+		closed = true
+		close(ch)
+		mu.Unlock()
+	}()
+	return ch, nil
+}
+
+// callbacks records which call installed the callback of each descriptor,
+// so that Notify only removes its own.
+var callbacks = callbackRegistry{byUd: make(map[int]uint64)}
+
+type callbackRegistry struct {
+	sync.Mutex
+	next uint64
+	byUd map[int]uint64 // the generation of the callback installed on ud
+}
+
+// install installs f on ud with Ibnotify and returns the result and the
+// generation of the callback, which is never 0.
+func (c *callbackRegistry) install(ud int, mask Status, f NotifyFunc) (Result, uint64) {
+	c.Lock()
+	defer c.Unlock()
+	r := driver().Ibnotify(ud, mask, f)
+	if r.Ibsta&ERR != 0 {
+		return r, 0
+	}
+	c.next++
+	c.byUd[ud] = c.next
+	if mask == 0 {
+		delete(c.byUd, ud)
+	}
+	return r, c.next
+}
+
+// remove removes the callback of ud if it is still the one of generation
+// gen.
+func (c *callbackRegistry) remove(ud int, gen uint64) {
+	c.Lock()
+	defer c.Unlock()
+	if c.byUd[ud] != gen {
+		return
+	}
+	delete(c.byUd, ud)
+	driver().Ibnotify(ud, 0, nil)
+}
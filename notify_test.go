@@ -0,0 +1,114 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNotify(t *testing.T) {
+	s := useSim(t)
+	inst, ud := simDev(t, s, T100ms, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := Notify(ctx, ud, RQS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stb := range []byte{0x01, 0x02} {
+		inst.RequestService(stb)
+		select {
+		case ev := <-ch:
+			if ev.Ud != ud || ev.Ibsta&RQS == 0 {
+				t.Errorf("event %+v, want RQS on %d", ev, ud)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("no event")
+		}
+		// Serial polling clears RQS, so the callback re-arms on the
+		// next request.
+		Ibrsp(ud)
+	}
+	cancel()
+	closed := make(chan struct{})
+	go func() {
+		for range ch {
+		}
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+}
+
+func TestNotifyReplaced(t *testing.T) {
+	s := useSim(t)
+	inst, ud := simDev(t, s, T100ms, 0)
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ch1, err := Notify(ctx1, ud, RQS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	ch, err := Notify(ctx2, ud, RQS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Ending the replaced Notify leaves the callback of the second one.
+	cancel1()
+	for range ch1 {
+	}
+	inst.RequestService(0x01)
+	select {
+	case ev := <-ch:
+		if ev.Ibsta&RQS == 0 {
+			t.Errorf("event %+v, want RQS", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event after the first Notify ended")
+	}
+	cancel2()
+	for range ch {
+	}
+}
+
+func TestIbnotifyRearm(t *testing.T) {
+	s := useSim(t)
+	_, ud := simDev(t, s, T30ms, 0)
+	calls := make(chan Status, 8)
+	_, err := Ibnotify(ud, TIMO, func(ud int, ibsta Status, iberr ErrorCode, ibcntl int) {
+		calls <- ibsta
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The callback re-arms with the same mask and is called after each
+	// timeout.
+	for i := 0; i < 2; i++ {
+		select {
+		case sta := <-calls:
+			if sta&TIMO == 0 {
+				t.Errorf("ibsta %v, want TIMO", sta)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%d callbacks, want 2", i)
+		}
+	}
+	if _, err := Ibnotify(ud, 0, nil); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	for len(calls) > 0 {
+		<-calls
+	}
+	select {
+	case <-calls:
+		t.Error("callback called after it was removed")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
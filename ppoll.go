@@ -0,0 +1,132 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ParallelPoll keeps track of the devices configured to answer parallel
+// polls on a board, one per data line, so that a single PPoll, one bus
+// cycle, tells which of up to eight devices request service.
+//
+// A device answers a parallel poll by asserting its data line when its
+// individual status (ist) bit equals the sense it was configured with;
+// IEEE 488.2 devices set ist while they request service. A device
+// configured with sense true thus asserts its line while it requests
+// service, and one configured with sense false releases it, which also
+// catches a device that is switched off.
+type ParallelPoll struct {
+	boardID int
+
+	mu    sync.Mutex
+	lines [8]ppLine // by data line - 1
+}
+
+type ppLine struct {
+	addr  Address
+	sense bool
+	used  bool
+}
+
+// NewParallelPoll returns a ParallelPoll of the board boardID with no
+// device assigned. Devices configured for parallel polls before are left
+// as they are, see Reset.
+func NewParallelPoll(boardID int) *ParallelPoll {
+	return &ParallelPoll{boardID: boardID}
+}
+
+// Assign configures the device at addr with Parallel Poll Enable (PPE) to
+// answer parallel polls on data line line, 1 to 8, with sense sense. A
+// device already assigned is moved to line. It fails if another device
+// has line.
+func (p *ParallelPoll) Assign(addr Address, line int, sense bool) error {
+	if line < 1 || line > 8 {
+		return fmt.Errorf("ni488: invalid parallel poll line %d", line)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if l := p.lines[line-1]; l.used && l.addr != addr {
+		return fmt.Errorf("ni488: parallel poll line %d is assigned to %v", line, l.addr)
+	}
+	if _, err := PPollConfig(p.boardID, line, btoi(sense), addr); err != nil {
+		return err
+	}
+	for i, l := range p.lines {
+		if l.used && l.addr == addr {
+			p.lines[i] = ppLine{}
+		}
+	}
+	p.lines[line-1] = ppLine{addr, sense, true}
+	return nil
+}
+
+// Remove unconfigures the device at addr for parallel polls with Parallel
+// Poll Disable (PPD) and frees its line.
+func (p *ParallelPoll) Remove(addr Address) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err := PPollUnconfig(p.boardID, []Address{addr}); err != nil {
+		return err
+	}
+	for i, l := range p.lines {
+		if l.used && l.addr == addr {
+			p.lines[i] = ppLine{}
+		}
+	}
+	return nil
+}
+
+// Reset unconfigures every device on the bus for parallel polls with
+// Parallel Poll Unconfigure (PPU), including those configured elsewhere,
+// and frees all lines.
+func (p *ParallelPoll) Reset() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err := PPollUnconfig(p.boardID, nil); err != nil {
+		return err
+	}
+	p.lines = [8]ppLine{}
+	return nil
+}
+
+// Line returns the data line and sense the device at addr is assigned,
+// ok is false if it has none.
+func (p *ParallelPoll) Line(addr Address) (line int, sense bool, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, l := range p.lines {
+		if l.used && l.addr == addr {
+			return i + 1, l.sense, true
+		}
+	}
+	return 0, false, false
+}
+
+// Poll conducts a parallel poll and returns the devices requesting
+// service, see Decode.
+func (p *ParallelPoll) Poll() ([]Address, error) {
+	ppr, _, err := PPoll(p.boardID)
+	if err != nil {
+		return nil, err
+	}
+	return p.Decode(byte(ppr)), nil
+}
+
+// Decode returns the assigned devices whose ist bit is set according to
+// the parallel poll response ppr, in the order of their lines. Bit 0 of
+// ppr is data line 1.
+func (p *ParallelPoll) Decode(ppr byte) []Address {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var addrs []Address
+	for i, l := range p.lines {
+		if l.used && (ppr&(1<<uint(i)) != 0) == l.sense {
+			addrs = append(addrs, l.addr)
+		}
+	}
+	return addrs
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParallelPollDecode(t *testing.T) {
+	useSim(t)
+	p := NewParallelPoll(0)
+	// Line 1 sense true, line 2 sense false, line 8 sense true.
+	for _, a := range []struct {
+		addr  Address
+		line  int
+		sense bool
+	}{{3, 1, true}, {4, 2, false}, {MakeAddr(5, 0x60), 8, true}} {
+		if err := p.Assign(a.addr, a.line, a.sense); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		ppr  byte
+		want []Address
+	}{
+		{0x00, []Address{4}}, // line 2 released, its device requests service
+		{0x02, nil},
+		{0x01, []Address{3, 4}},
+		{0x83, []Address{3, MakeAddr(5, 0x60)}},
+		{0x7E, nil}, // the unassigned lines 3 to 7 are ignored
+		{0xFF, []Address{3, MakeAddr(5, 0x60)}},
+	}
+	for _, tt := range tests {
+		if got := p.Decode(tt.ppr); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Decode(%#02x) = %v, want %v", tt.ppr, got, tt.want)
+		}
+	}
+}
+
+func TestParallelPollAssign(t *testing.T) {
+	s := useSim(t)
+	insts := map[Address]*SimInstrument{}
+	for _, a := range []Address{3, 4, MakeAddr(5, 0x60)} {
+		insts[a] = NewSimInstrument(nil)
+		s.Attach(0, a.Primary(), a.Secondary(), insts[a])
+	}
+	b := NewBoard(0)
+	p := b.ParallelPoll()
+	if err := p.Assign(3, 1, true); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []int{0, 9} {
+		if err := p.Assign(4, line, true); err == nil {
+			t.Errorf("Assign to line %d did not fail", line)
+		}
+	}
+	if err := p.Assign(4, 1, true); err == nil {
+		t.Error("Assign to the line of another device did not fail")
+	}
+	p.Assign(4, 2, true)
+	p.Assign(MakeAddr(5, 0x60), 8, true)
+
+	if got, err := p.Poll(); err != nil || got != nil {
+		t.Errorf("Poll with no request = %v, %v", got, err)
+	}
+	for _, inst := range insts {
+		inst.RequestService(0x41)
+	}
+	if got, _ := p.Poll(); !reflect.DeepEqual(got, []Address{3, 4, MakeAddr(5, 0x60)}) {
+		t.Errorf("Poll = %v, want all", got)
+	}
+
+	// Moving a device frees its old line.
+	if err := p.Assign(3, 6, false); err != nil {
+		t.Fatal(err)
+	}
+	if line, sense, ok := p.Line(3); line != 6 || sense || !ok {
+		t.Errorf("Line(3) = %d, %v, %v; want 6, false", line, sense, ok)
+	}
+	if err := p.Assign(4, 1, true); err != nil {
+		t.Errorf("Assign to the freed line 1: %v", err)
+	}
+	if err := p.Remove(4); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := p.Line(4); ok {
+		t.Error("removed device still has a line")
+	}
+	// Line 6 is released, sense false, line 8 asserted.
+	if ppr, err := b.PPoll(); err != nil || ppr != 0x80 {
+		t.Errorf("PPoll = %#x, %v; want 0x80", ppr, err)
+	}
+	if got, _ := p.Poll(); !reflect.DeepEqual(got, []Address{3, MakeAddr(5, 0x60)}) {
+		t.Errorf("Poll = %v", got)
+	}
+
+	if err := p.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if ppr, _ := b.PPoll(); ppr != 0 {
+		t.Errorf("PPoll after Reset = %#x", ppr)
+	}
+	if got, _ := p.Poll(); got != nil {
+		t.Errorf("Poll after Reset = %v", got)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"context"
+	"io"
+)
+
+// readChunk is the least free space ReadMessage reads into, the buffer is
+// grown to have it.
+const readChunk = 512
+
+// Read reads up to len(buf) bytes from ud with Ibrd. It returns the number
+// of bytes read, taken from ibcntl, and whether the read stopped on END,
+// EOI or the EOS character, which ends a message. n can be non-zero even
+// when the read fails, e.g. on a timeout.
+func Read(ud int, buf []byte) (n int, end bool, err error) {
+	return ReadContext(context.Background(), ud, buf)
+}
+
+// ReadContext is Read, aborted with Ibstop when ctx is done.
+func ReadContext(ctx context.Context, ud int, buf []byte) (n int, end bool, err error) {
+	r, err := IbrdContext(ctx, ud, buf)
+	return r.count(len(buf)), r.Ibsta.End(), err
+}
+
+// ReadMessage reads from ud until END and returns buf, which may be nil,
+// with the message appended. buf is grown as needed across as many Ibrd
+// calls as the message takes, for responses of unknown length. On an error
+// the bytes read so far are returned along with it.
+func ReadMessage(ud int, buf []byte) ([]byte, error) {
+	return ReadMessageContext(context.Background(), ud, buf)
+}
+
+// ReadMessageContext is ReadMessage, aborted with Ibstop when ctx is done.
+func ReadMessageContext(ctx context.Context, ud int, buf []byte) ([]byte, error) {
+	return readMessage(buf, func(p []byte) (int, bool, error) {
+		return ReadContext(ctx, ud, p)
+	})
+}
+
+// ReadAll reads a whole message from ud, see ReadMessage.
+func ReadAll(ud int) ([]byte, error) {
+	return ReadMessage(ud, nil)
+}
+
+// readMessage appends to buf with read until it reports END.
+func readMessage(buf []byte, read func(p []byte) (n int, end bool, err error)) ([]byte, error) {
+	for {
+		if cap(buf)-len(buf) < readChunk {
+			buf = append(buf, make([]byte, readChunk)...)[:len(buf)]
+		}
+		n, end, err := read(buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		switch {
+		case err != nil || end:
+			return buf, err
+		case n == 0:
+			return buf, io.ErrNoProgress
+		}
+	}
+}
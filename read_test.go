@@ -0,0 +1,93 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReadEnd(t *testing.T) {
+	s := useSim(t)
+	inst, ud := simDev(t, s, T30ms, 0)
+	inst.Queue([]byte("ACME,1,2,3\n"))
+	if n, end, err := Read(ud, make([]byte, 4)); n != 4 || end || err != nil {
+		t.Errorf("Read of 4 = %d, %v, %v; want no END", n, end, err)
+	}
+	if n, end, err := Read(ud, make([]byte, 64)); n != 7 || !end || err != nil {
+		t.Errorf("Read of the rest = %d, %v, %v; want END", n, end, err)
+	}
+	if n, end, err := Read(ud, make([]byte, 64)); n != 0 || end || !errors.Is(err, ErrTimeout) {
+		t.Errorf("Read of nothing = %d, %v, %v; want a timeout", n, end, err)
+	}
+}
+
+func TestReadMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		size   int
+	}{
+		{"short", "", 10},
+		{"chunk", "", readChunk},
+		{"chunk+1", "", readChunk + 1},
+		{"chunks", "", 5*readChunk + 7},
+		{"appended", "x:", 2*readChunk + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := useSim(t)
+			inst, ud := simDev(t, s, T100ms, 0)
+			msg := strings.Repeat("0123456789", tt.size/10+1)[:tt.size-1] + "\n"
+			inst.Queue([]byte(msg))
+			inst.Queue([]byte("next\n"))
+			got, err := ReadMessage(ud, []byte(tt.prefix))
+			if err != nil || string(got) != tt.prefix+msg {
+				t.Errorf("ReadMessage = %d bytes, %v; want %d", len(got), err, len(tt.prefix+msg))
+			}
+			// The message after it is left alone.
+			if got, err := ReadAll(ud); err != nil || string(got) != "next\n" {
+				t.Errorf("ReadAll = %q, %v; want the next message", got, err)
+			}
+		})
+	}
+}
+
+func TestReadMessageTimeout(t *testing.T) {
+	s := useSim(t)
+	_, ud := simDev(t, s, T30ms, 0)
+	msg, err := ReadMessage(ud, []byte("x:"))
+	if string(msg) != "x:" || !errors.Is(err, ErrTimeout) {
+		t.Errorf("ReadMessage with nothing to read = %q, %v; want a timeout", msg, err)
+	}
+}
+
+func TestReadMessageEOS(t *testing.T) {
+	s := useSim(t)
+	inst, ud := simDev(t, s, T30ms, REOS|'\n')
+	inst.Queue([]byte(strings.Repeat("a", readChunk+3) + "\nb"))
+	msg, err := ReadMessage(ud, nil)
+	if err != nil || len(msg) != readChunk+4 || msg[len(msg)-1] != '\n' {
+		t.Errorf("ReadMessage = %d bytes, %v; want %d ending at EOS", len(msg), err, readChunk+4)
+	}
+}
+
+func TestReceiveMessage(t *testing.T) {
+	s := useSim(t)
+	long := strings.Repeat("0123456789", 300) + "\n"
+	s.Attach(0, 5, NO_SAD, NewSimInstrument(func(msg string) []byte {
+		return []byte(long)
+	}))
+	b := NewBoard(0)
+	if _, err := b.Send(5, []byte("LONG?\n"), DABend); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := b.ReceiveMessage(5, STOPend)
+	if err != nil || !bytes.Equal(msg, []byte(long)) {
+		t.Errorf("ReceiveMessage = %d bytes, %v; want %d", len(msg), err, len(long))
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"errors"
+	"strings"
+)
+
+// ScanOptions configures Scan. A nil *ScanOptions uses the defaults.
+type ScanOptions struct {
+	// Pads are the primary addresses probed, 0 to 30 except the address
+	// of the board itself if empty.
+	Pads []int
+
+	// Secondary has the secondary addresses, 96 to 126, of each primary
+	// address probed as well.
+	Secondary bool
+
+	// Timeout is the I/O timeout of the *IDN? query, T300ms if zero.
+	Timeout Timeout
+}
+
+func (o *ScanOptions) timeout() Timeout {
+	if o == nil || o.Timeout == TNONE {
+		return T300ms
+	}
+	return o.Timeout
+}
+
+// IDN is a response to *IDN?, the identification query of IEEE 488.2,
+// split into its four fields.
+type IDN struct {
+	Manufacturer string
+	Model        string
+	Serial       string // "0" if the device does not report one
+	Firmware     string
+}
+
+// ParseIDN splits an *IDN? response into its fields, which are separated
+// by commas. Surrounding white space and the terminator are removed, and
+// missing fields are left empty; any further commas are kept in Firmware.
+func ParseIDN(s string) IDN {
+	f := strings.SplitN(strings.TrimSpace(s), ",", 4)
+	for i := range f {
+		f[i] = strings.TrimSpace(f[i])
+	}
+	f = append(f, "", "", "")
+	return IDN{f[0], f[1], f[2], f[3]}
+}
+
+// Listener is a device found by Scan.
+type Listener struct {
+	Addr Address
+
+	// Response is the *IDN? response with its terminator removed, empty
+	// if the device did not answer, as devices that are not IEEE 488.2
+	// compliant may not, and IDN holds its fields.
+	Response string
+	IDN
+
+	// Err is set if the device could not be queried for another reason
+	// than a timeout.
+	Err error
+}
+
+// Identified reports whether the device answered *IDN?.
+func (l Listener) Identified() bool {
+	return l.Response != ""
+}
+
+// Scan finds the devices listening on the board boardID and identifies
+// each with *IDN?, returning them in address order. A device that does
+// not answer within the timeout of opts is still listed, with an empty
+// Response; the error of a device that fails otherwise is in its Err.
+// Scan only returns an error if the bus cannot be probed.
+func Scan(boardID int, opts *ScanOptions) ([]Listener, error) {
+	addrs, err := listeners(boardID, opts)
+	if err != nil {
+		return nil, err
+	}
+	found := make([]Listener, len(addrs))
+	for i, a := range addrs {
+		found[i] = identify(boardID, a, opts.timeout())
+	}
+	return found, nil
+}
+
+// listeners returns the addresses of the devices on the bus, found with
+// Ibln.
+func listeners(boardID int, opts *ScanOptions) ([]Address, error) {
+	var pads []int
+	if opts != nil {
+		pads = opts.Pads
+	}
+	if len(pads) == 0 {
+		own, _, err := Ibask(boardID, IbaPAD)
+		if err != nil {
+			return nil, err
+		}
+		for pad := 0; pad <= 30; pad++ {
+			if pad != own {
+				pads = append(pads, pad)
+			}
+		}
+	}
+	sads := []int{NO_SAD}
+	if opts != nil && opts.Secondary {
+		for sad := 0x60; sad <= 0x7E; sad++ {
+			sads = append(sads, sad)
+		}
+	}
+	var addrs []Address
+	for _, pad := range pads {
+		if len(sads) > 1 {
+			// Skip the secondary addresses of a primary address
+			// nothing listens at in one call.
+			ok, _, err := Ibln(boardID, pad, ALL_SAD)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		for _, sad := range sads {
+			addr := MakeAddr(pad, sad)
+			ok, _, err := IblnAddr(boardID, addr)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+	return addrs, nil
+}
+
+// identify queries the device at addr with *IDN?.
+func identify(boardID int, addr Address, tmo Timeout) Listener {
+	l := Listener{Addr: addr}
+	dev, err := OpenDevice(boardID, addr, tmo, 1, EOSConfig{})
+	if err != nil {
+		l.Err = err
+		return l
+	}
+	defer dev.Close()
+	// Drop anything left from before, e.g. an unread response.
+	if err := dev.Clear(); err != nil && !errors.Is(err, ErrTimeout) {
+		l.Err = err
+		return l
+	}
+	_, err = dev.Write([]byte("*IDN?\n"))
+	var msg []byte
+	if err == nil {
+		msg, err = dev.ReadMessage()
+	}
+	if err != nil {
+		if !errors.Is(err, ErrTimeout) {
+			l.Err = err
+		}
+		return l
+	}
+	l.Response = strings.TrimSpace(string(msg))
+	l.IDN = ParseIDN(l.Response)
+	return l
+}
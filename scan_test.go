@@ -0,0 +1,72 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseIDN(t *testing.T) {
+	tests := []struct {
+		s    string
+		want IDN
+	}{
+		{"HEWLETT-PACKARD,34401A,0,11-5-2\n", IDN{"HEWLETT-PACKARD", "34401A", "0", "11-5-2"}},
+		{" ACME, Model 9 ,SN42 , 1.0 \r\n", IDN{"ACME", "Model 9", "SN42", "1.0"}},
+		{"ACME,X,1,2.0,beta,3", IDN{"ACME", "X", "1", "2.0,beta,3"}},
+		{"ACME,X", IDN{"ACME", "X", "", ""}},
+		{"ACME", IDN{Manufacturer: "ACME"}},
+		{"", IDN{}},
+		{",,,", IDN{}},
+	}
+	for _, tt := range tests {
+		if got := ParseIDN(tt.s); got != tt.want {
+			t.Errorf("ParseIDN(%q) = %+v, want %+v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestScanModels(t *testing.T) {
+	s := useSim(t)
+	var want []Listener
+	for _, m := range attachModels(t, s) {
+		want = append(want, Listener{Addr: MakeAddr(m.Pad, m.Sad), Response: m.IDN, IDN: ParseIDN(m.IDN)})
+	}
+	// A device that is not IEEE 488.2 compliant and does not answer.
+	s.Attach(0, 7, NO_SAD, NewSimInstrument(nil))
+	want = append(want, Listener{Addr: 7})
+	// Only found when the secondary addresses are probed.
+	s.Attach(0, 9, 0x61, NewSimInstrument(func(string) []byte { return []byte("ACME,SUB,1,0\n") }))
+
+	found, err := Scan(0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sortListeners(want)
+	if !reflect.DeepEqual(found, want) {
+		t.Errorf("Scan found\n%+v\nwant\n%+v", found, want)
+	}
+	for _, l := range found {
+		if l.Identified() != (l.Addr != 7) {
+			t.Errorf("%v Identified = %v", l.Addr, l.Identified())
+		}
+	}
+
+	found, err = NewBoard(0).Scan(&ScanOptions{Pads: []int{5, 9, 10}, Secondary: true, Timeout: T100ms})
+	wantSub := []Listener{want[1], {Addr: MakeAddr(9, 0x61), Response: "ACME,SUB,1,0", IDN: IDN{"ACME", "SUB", "1", "0"}}}
+	if err != nil || !reflect.DeepEqual(found, wantSub) {
+		t.Errorf("Scan of 5, 9 and 10 = %+v, %v; want %+v", found, err, wantSub)
+	}
+}
+
+// sortListeners sorts l by address, as Scan returns them.
+func sortListeners(l []Listener) {
+	for i := 1; i < len(l); i++ {
+		for j := i; j > 0 && l[j].Addr < l[j-1].Addr; j-- {
+			l[j], l[j-1] = l[j-1], l[j]
+		}
+	}
+}
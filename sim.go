@@ -0,0 +1,1864 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SimDevice is an instrument attached to a simulated bus.
+//
+// The Sim calls Listen, Clear, Trigger, StatusByte and SerialPoll while
+// holding its own lock, so a SimDevice must not call back into the Sim.
+type SimDevice interface {
+	// Listen receives data bytes sent to the device while it is addressed
+	// to listen. end is set when the last byte was sent with EOI. data is
+	// the caller's buffer and must not be kept after Listen returns.
+	Listen(data []byte, end bool)
+
+	// Talk returns the next message the device sends when it is addressed
+	// to talk, waiting until ctx is done if nothing is ready. The last
+	// byte of the message is sent with EOI.
+	Talk(ctx context.Context) ([]byte, error)
+
+	// Clear handles the Selected Device Clear and Device Clear messages.
+	Clear()
+
+	// Trigger handles the Group Execute Trigger message.
+	Trigger()
+
+	// StatusByte returns the serial poll status byte without changing it.
+	// The device requests service while bit 6 (0x40) is set.
+	StatusByte() byte
+
+	// SerialPoll returns the status byte and clears the request for
+	// service, as happens when the Controller serial polls the device.
+	SerialPoll() byte
+}
+
+// simRQS is the request service bit of a serial poll status byte.
+const simRQS = 0x40
+
+// Sim is a Driver that simulates GPIB boards and the devices on their bus
+// in pure Go. Devices sit at primary/secondary addresses and are reached
+// through the usual talker/listener addressing, so calls report ibsta,
+// iberr and ibcntl as the NI driver would, e.g. ENOL for a write nobody
+// listens to and TIMO with EABO for a read nobody answers.
+//
+// Boards start out as System Controller and Controller-In-Charge. Board
+// descriptors equal the board index, device descriptors start at 32.
+// Every call returns its own Result; ThreadIbsta, ThreadIberr and
+// ThreadIbcntl report the last call made by any goroutine.
+type Sim struct {
+	mu     sync.Mutex
+	boards map[int]*simBoard
+	descs  map[int]*simDesc
+	next   int
+
+	sta, err, cntl uint32
+}
+
+type simDesc struct {
+	board    *simBoard
+	dev      bool
+	pad, sad int
+	tmo      Timeout
+	eot      int
+	eos      int
+	opts     map[ConfigOption]int
+	notify   int           // bumped by every Ibnotify, stopping the callback before it
+	async    int           // ibcntl of the last asynchronous I/O, for Ibwait
+	stop     chan struct{} // closed by Ibstop and Ibonl to abort a blocked call
+}
+
+type simBoard struct {
+	index     int
+	cfg       simDesc
+	sc, cic   bool
+	ren, llo  bool
+	talker    *simSlot
+	listeners map[*simSlot]bool
+	slots     map[Address]*simSlot
+	held      bool // the interface lock is held by another process
+}
+
+type simSlot struct {
+	dev      SimDevice
+	pad, sad int
+	pending  []byte
+	ppr      int
+}
+
+// NewSim returns a simulator with a single board, GPIB0.
+func NewSim() *Sim {
+	s := &Sim{
+		boards: make(map[int]*simBoard),
+		descs:  make(map[int]*simDesc),
+		next:   32,
+	}
+	s.AddBoard(0)
+	return s
+}
+
+// AddBoard adds the board GPIBn, where n is index. Adding an existing
+// board is a no-op.
+func (s *Sim) AddBoard(index int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.boards[index] != nil {
+		return
+	}
+	b := &simBoard{
+		index:     index,
+		sc:        true,
+		cic:       true,
+		listeners: make(map[*simSlot]bool),
+		slots:     make(map[Address]*simSlot),
+	}
+	b.cfg = simDesc{board: b, tmo: T10s, eot: 1, opts: map[ConfigOption]int{IbcSC: 1}}
+	s.boards[index] = b
+	s.descs[index] = &b.cfg
+}
+
+// Attach places d on the bus of board boardID at primary address pad and
+// secondary address sad, where sad is 0 (NO_SAD) or 0x60 to 0x7E.
+func (s *Sim) Attach(boardID, pad, sad int, d SimDevice) error {
+	if !validPad(pad) || !validSad(sad) {
+		return fmt.Errorf("ni488: invalid address %d/%d", pad, sad)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.boards[boardID]
+	if b == nil {
+		return fmt.Errorf("ni488: no simulated board GPIB%d", boardID)
+	}
+	key := MakeAddr(pad, sad)
+	if b.slots[key] != nil {
+		return fmt.Errorf("ni488: address %d/%d is in use on GPIB%d", pad, sad, boardID)
+	}
+	b.slots[key] = &simSlot{dev: d, pad: pad, sad: sad}
+	return nil
+}
+
+// Detach removes the device at pad/sad from the bus of board boardID.
+func (s *Sim) Detach(boardID, pad, sad int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.boards[boardID]
+	if b == nil {
+		return
+	}
+	if sl := b.slots[MakeAddr(pad, sad)]; sl != nil {
+		if b.talker == sl {
+			b.talker = nil
+		}
+		delete(b.listeners, sl)
+		delete(b.slots, MakeAddr(pad, sad))
+	}
+}
+
+// HoldLock makes the interface lock of board boardID held by another
+// process, so Iblck fails with ELCK, until it is called with held false.
+func (s *Sim) HoldLock(boardID int, held bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b := s.boards[boardID]; b != nil {
+		b.held = held
+	}
+}
+
+func validPad(pad int) bool { return pad >= 0 && pad <= 30 }
+
+func validSad(sad int) bool { return sad == NO_SAD || (sad >= 0x60 && sad <= 0x7E) }
+
+// done records the result of a call made through d and returns it.
+func (s *Sim) done(d *simDesc, sta Status, iberr ErrorCode, cntl int) Result {
+	if d != nil && !d.dev && d.board.cic {
+		sta |= CIC
+	}
+	s.sta, s.err, s.cntl = uint32(sta), uint32(iberr), uint32(cntl)
+	return s.last()
+}
+
+// last returns the result recorded by the call in progress.
+func (s *Sim) last() Result {
+	return Result{Status(s.sta), ErrorCode(s.err), int(s.cntl)}
+}
+
+func (s *Sim) ok(d *simDesc, cntl int) Result {
+	return s.done(d, CMPL, 0, cntl)
+}
+
+func (s *Sim) fail(d *simDesc, iberr ErrorCode, cntl int) Result {
+	return s.done(d, ERR|CMPL, iberr, cntl)
+}
+
+func (s *Sim) timedOut(d *simDesc, cntl int) Result {
+	if d != nil && d.stopped() {
+		return s.done(d, ERR|CMPL, EABO, cntl)
+	}
+	return s.done(d, ERR|TIMO|CMPL, EABO, cntl)
+}
+
+// stopper returns the channel closed when the call about to block on d is
+// aborted by Ibstop or Ibonl.
+func (d *simDesc) stopper() chan struct{} {
+	if d.stop == nil || d.stopped() {
+		d.stop = make(chan struct{})
+	}
+	return d.stop
+}
+
+// stopped reports whether the last call to block on d was aborted.
+func (d *simDesc) stopped() bool {
+	select {
+	case <-d.stop:
+		return d.stop != nil
+	default:
+		return false
+	}
+}
+
+// abort aborts the call blocked on d, if any.
+func (d *simDesc) abort() {
+	if d.stop != nil && !d.stopped() {
+		close(d.stop)
+	}
+}
+
+// desc looks up ud and records EDVR if it is not a valid descriptor.
+func (s *Sim) desc(ud int) *simDesc {
+	d := s.descs[ud]
+	if d == nil {
+		s.fail(nil, EDVR, 0)
+	}
+	return d
+}
+
+// boardDesc looks up a board descriptor for the 488.2 routines and
+// records ENEB if there is no such board.
+func (s *Sim) boardDesc(boardID int) *simDesc {
+	b := s.boards[boardID]
+	if b == nil {
+		s.fail(nil, ENEB, 0)
+		return nil
+	}
+	return &b.cfg
+}
+
+// wait releases the lock for the duration of the timeout code tmo, which
+// is forever for TNONE, or until the call blocked on d is aborted.
+func (s *Sim) wait(d *simDesc, tmo Timeout) {
+	stop := d.stopper()
+	var timeout <-chan time.Time
+	if tmo > TNONE && int(tmo) < len(timeoutDurations) {
+		t := time.NewTimer(timeoutDurations[tmo])
+		defer t.Stop()
+		timeout = t.C
+	}
+	s.mu.Unlock()
+	defer s.mu.Lock()
+	select {
+	case <-stop:
+	case <-timeout:
+	}
+}
+
+func timeoutContext(tmo Timeout) (context.Context, context.CancelFunc) {
+	if tmo <= TNONE || int(tmo) >= len(timeoutDurations) {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), timeoutDurations[tmo])
+}
+
+// command interprets cmds as GPIB command bytes on the bus of b.
+func (b *simBoard) command(cmds []byte) {
+	var lad, tad = -1, -1
+	ppc := false
+	for _, cmd := range cmds {
+		c := Command(cmd & 0x7F)
+		switch {
+		case c == UNL:
+			b.listeners = make(map[*simSlot]bool)
+			lad = -1
+		case c == UNT:
+			b.talker = nil
+			tad = -1
+		case c >= 0x20 && c < 0x3F:
+			lad, tad = int(c-0x20), -1
+			if sl := b.slots[MakeAddr(lad, 0)]; sl != nil && lad != b.cfg.pad {
+				b.listeners[sl] = true
+			}
+		case c >= 0x40 && c < 0x5F:
+			tad, lad = int(c-0x40), -1
+			b.talker = b.slots[MakeAddr(tad, 0)]
+		case c >= 0x60 && ppc:
+			for sl := range b.listeners {
+				if c == PPD {
+					sl.ppr = 0
+				} else if c < PPD {
+					sl.ppr = int(c)
+				}
+			}
+		case c >= 0x60 && c < 0x7F && lad >= 0:
+			if sl := b.slots[MakeAddr(lad, int(c))]; sl != nil {
+				b.listeners[sl] = true
+			}
+		case c >= 0x60 && c < 0x7F && tad >= 0:
+			if sl := b.slots[MakeAddr(tad, int(c))]; sl != nil {
+				b.talker = sl
+			}
+		case c == SDC:
+			for sl := range b.listeners {
+				sl.clear()
+			}
+		case c == DCL:
+			for _, sl := range b.slots {
+				sl.clear()
+			}
+		case c == GET:
+			for sl := range b.listeners {
+				sl.dev.Trigger()
+			}
+		case c == LLO:
+			b.llo = true
+		case c == PPU:
+			for _, sl := range b.slots {
+				sl.ppr = 0
+			}
+		case c == TCT:
+			if b.talker != nil {
+				b.cic = false
+			}
+		}
+		ppc = c == PPC
+	}
+}
+
+func (sl *simSlot) clear() {
+	sl.pending = nil
+	sl.dev.Clear()
+}
+
+// unaddress sends UNL and UNT.
+func (b *simBoard) unaddress() {
+	b.command([]byte{byte(UNL), byte(UNT)})
+}
+
+// listen addresses the devices at addrs to listen and the board to talk.
+func (b *simBoard) listen(addrs ...Address) {
+	cmds := []byte{byte(UNL), byte(0x40 | b.cfg.pad)}
+	for _, a := range addrs {
+		cmds = append(cmds, byte(0x20|a&0xFF))
+		if sad := byte(a >> 8); sad != 0 {
+			cmds = append(cmds, sad)
+		}
+	}
+	b.command(cmds)
+}
+
+// talk addresses the device at addr to talk and the board to listen.
+func (b *simBoard) talk(addr Address) {
+	cmds := []byte{byte(UNL), byte(0x20 | b.cfg.pad), byte(0x40 | addr&0xFF)}
+	if sad := byte(addr >> 8); sad != 0 {
+		cmds = append(cmds, sad)
+	}
+	b.command(cmds)
+}
+
+func (b *simBoard) srq() bool {
+	for _, sl := range b.slots {
+		if sl.dev.StatusByte()&simRQS != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// sendData writes data to the current listeners, returning false if
+// there are none.
+func (b *simBoard) sendData(data []byte, end bool) bool {
+	if len(b.listeners) == 0 {
+		return false
+	}
+	for sl := range b.listeners {
+		sl.dev.Listen(data, end)
+	}
+	return true
+}
+
+// receive reads into buf from the current talker of b. eos is an EOS
+// configuration as passed to ibeos.
+func (s *Sim) receive(d *simDesc, buf []byte, tmo Timeout, eos int) (n int, end, ok bool) {
+	b := d.board
+	sl := b.talker
+	if sl == nil {
+		s.wait(d, tmo)
+		return 0, false, false
+	}
+	if len(sl.pending) == 0 {
+		ctx, cancel := timeoutContext(tmo)
+		stop := d.stopper()
+		go func() {
+			select {
+			case <-stop:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		s.mu.Unlock()
+		msg, err := sl.dev.Talk(ctx)
+		s.mu.Lock()
+		cancel()
+		if err != nil {
+			return 0, false, false
+		}
+		if len(msg) == 0 {
+			return 0, true, true
+		}
+		sl.pending = msg
+	}
+	for n < len(buf) && n < len(sl.pending) {
+		c := sl.pending[n]
+		buf[n] = c
+		n++
+		if eosMatch(c, eos) {
+			end = true
+			break
+		}
+	}
+	sl.pending = sl.pending[n:]
+	if len(sl.pending) == 0 {
+		sl.pending, end = nil, true
+	}
+	return n, end, true
+}
+
+func eosMatch(c byte, eos int) bool {
+	if eos&REOS == 0 {
+		return false
+	}
+	if eos&BIN != 0 {
+		return c == byte(eos)
+	}
+	return c&0x7F == byte(eos)&0x7F
+}
+
+// read performs ibrd on d.
+func (s *Sim) read(d *simDesc, buf []byte) Result {
+	b := d.board
+	if d.dev {
+		if !b.cic {
+			return s.fail(d, ECIC, 0)
+		}
+		b.talk(MakeAddr(d.pad, d.sad))
+	}
+	n, end, ok := s.receive(d, buf, d.tmo, d.eos)
+	if !ok {
+		return s.timedOut(d, n)
+	}
+	if end {
+		return s.done(d, END|CMPL, 0, n)
+	}
+	return s.ok(d, n)
+}
+
+// write performs ibwrt on d.
+func (s *Sim) write(d *simDesc, buf []byte) Result {
+	b := d.board
+	if d.dev {
+		if !b.cic {
+			return s.fail(d, ECIC, 0)
+		}
+		b.listen(MakeAddr(d.pad, d.sad))
+	}
+	end := d.eot != 0
+	if n := len(buf); n > 0 && d.eos&XEOS != 0 && buf[n-1] == byte(d.eos) {
+		end = true
+	}
+	if !b.sendData(buf, end) {
+		return s.fail(d, ENOL, 0)
+	}
+	return s.ok(d, len(buf))
+}
+
+// cmd performs ibcmd on the board described by d.
+func (s *Sim) cmd(d *simDesc, cmds []byte) Result {
+	if d.dev {
+		return s.fail(d, EARG, 0)
+	}
+	if !d.board.cic {
+		return s.fail(d, ECIC, 0)
+	}
+	if len(d.board.slots) == 0 {
+		return s.fail(d, ENOL, 0)
+	}
+	d.board.command(cmds)
+	return s.ok(d, len(cmds))
+}
+
+// devCmd addresses the device described by d to listen and sends cmd.
+func (s *Sim) devCmd(d *simDesc, cmd Command) Result {
+	if !d.board.cic {
+		return s.fail(d, ECIC, 0)
+	}
+	if len(d.board.slots) == 0 {
+		return s.fail(d, ENOL, 0)
+	}
+	d.board.listen(MakeAddr(d.pad, d.sad))
+	d.board.command([]byte{byte(cmd)})
+	return s.ok(d, 1)
+}
+
+// spoll serial polls the device at addr on the board described by d.
+func (s *Sim) spoll(d *simDesc, addr Address) (byte, bool) {
+	b := d.board
+	sl := b.slots[addr]
+	if sl == nil {
+		s.wait(d, d.tmo)
+		return 0, false
+	}
+	b.unaddress()
+	return sl.dev.SerialPoll(), true
+}
+
+// ppoll conducts a parallel poll on the bus of b.
+func (b *simBoard) ppoll() byte {
+	var ppr byte
+	for _, sl := range b.slots {
+		if sl.ppr == 0 {
+			continue
+		}
+		sense := sl.ppr >> 3 & 1
+		ist := 0
+		if sl.dev.StatusByte()&simRQS != 0 {
+			ist = 1
+		}
+		if ist == sense {
+			ppr |= 1 << uint(sl.ppr&7)
+		}
+	}
+	return ppr
+}
+
+// status returns the wait status of d.
+func (s *Sim) status(d *simDesc) Status {
+	sta := CMPL
+	b := d.board
+	if d.dev {
+		if sl := b.slots[MakeAddr(d.pad, d.sad)]; sl != nil &&
+			sl.dev.StatusByte()&simRQS != 0 {
+			sta |= RQS
+		}
+		return sta
+	}
+	if b.srq() {
+		sta |= SRQI
+	}
+	if b.ren {
+		sta |= REM
+	}
+	if b.llo {
+		sta |= LOK
+	}
+	return sta
+}
+
+func (s *Sim) eos(d *simDesc, option ConfigOption, v int) {
+	switch option {
+	case IbcEOSrd:
+		d.eos = setFlag(d.eos, REOS, v)
+	case IbcEOSwrt:
+		d.eos = setFlag(d.eos, XEOS, v)
+	case IbcEOScmp:
+		d.eos = setFlag(d.eos, BIN, v)
+	case IbcEOSchar:
+		d.eos = d.eos&^0xFF | v&0xFF
+	}
+}
+
+func setFlag(v, flag, on int) int {
+	if on != 0 {
+		return v | flag
+	}
+	return v &^ flag
+}
+
+func flag(v, flag int) int {
+	return btoi(v&flag != 0)
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+//  NI-488 Functions
+
+func (s *Sim) Ibask(ud int, option ConfigOption) (int, Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.desc(ud)
+	if d == nil {
+		return 0, s.last()
+	}
+	v, iberr, ok := s.ask(d, option)
+	if !ok {
+		return 0, s.fail(d, iberr, 0)
+	}
+	return v, s.ok(d, 0)
+}
+
+// ask returns the value of option for d, or the error code for asking
+// an option d does not have.
+func (s *Sim) ask(d *simDesc, option ConfigOption) (v int, iberr ErrorCode, ok bool) {
+	switch option {
+	case IbaPAD:
+		v = d.pad
+	case IbaSAD:
+		v = d.sad
+	case IbaTMO:
+		v = int(d.tmo)
+	case IbaEOT:
+		v = d.eot
+	case IbaEOSrd:
+		v = flag(d.eos, REOS)
+	case IbaEOSwrt:
+		v = flag(d.eos, XEOS)
+	case IbaEOScmp:
+		v = flag(d.eos, BIN)
+	case IbaEOSchar:
+		v = d.eos & 0xFF
+	case IbaSC:
+		if d.dev {
+			return 0, ECAP, false
+		}
+		v = btoi(d.board.sc)
+	case IbaSRE:
+		v = btoi(d.board.ren)
+	case IbaBNA:
+		if !d.dev {
+			return 0, EARG, false
+		}
+		v = d.board.index
+	case IbaSerialNumber:
+		v = 0x488 + d.board.index
+	case IbaPPC, IbaREADDR, IbaAUTOPOLL, IbaPP2, IbaTIMING, IbaDMA,
+		IbaSendLLO, IbaSPollTime, IbaPPollTime, IbaEndBitIsNormal,
+		IbaUnAddr, IbaHSCableLength, IbaIst, IbaRsv, IbaLON:
+		v = d.opts[option]
+	default:
+		return 0, EARG, false
+	}
+	return v, 0, true
+}
+
+func (s *Sim) Ibbna(ud int, udname string) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.desc(ud)
+	switch {
+	case d == nil:
+		return s.last()
+	case !d.dev:
+		return s.fail(d, EARG, 0)
+	}
+	i, ok := boardIndex(udname)
+	switch {
+	case !ok:
+		return s.fail(d, EARG, 0)
+	case s.boards[i] == nil:
+		return s.fail(d, ENEB, 0)
+	}
+	// On success iberr holds the previous access board.
+	prev := d.board.index
+	d.board = s.boards[i]
+	return s.done(d, CMPL, ErrorCode(prev), 0)
+}
+
+func (s *Sim) Ibcac(ud, v int) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.desc(ud)
+	switch {
+	case d == nil:
+		return s.last()
+	case d.dev:
+		return s.fail(d, EARG, 0)
+	case !d.board.cic:
+		return s.fail(d, ECIC, 0)
+	}
+	return s.ok(d, 0)
+}
+
+func (s *Sim) Ibclr(ud int) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.desc(ud)
+	switch {
+	case d == nil:
+		return s.last()
+	case !d.dev:
+		return s.fail(d, EARG, 0)
+	}
+	return s.devCmd(d, SDC)
+}
+
+func (s *Sim) Ibcmd(ud int, cmds []byte) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.desc(ud)
+	if d == nil {
+		return s.last()
+	}
+	return s.cmd(d, cmds)
+}
+
+func (s *Sim) Ibcmda(ud int, cmds []byte) Result {
+	return s.async(ud, s.Ibcmd(ud, cmds))
+}
+
+func (s *Sim) Ibconfig(ud int, option ConfigOption, v int) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.desc(ud)
+	if d == nil {
+		return s.last()
+	}
+	prev, _, ok := s.ask(d, option)
+	if !ok || option == IbaBNA || option == IbaSerialNumber {
+		return s.fail(d, EARG, 0)
+	}
+	switch option {
+	case IbcPAD:
+		if !validPad(v) {
+			return s.fail(d, EARG, 0)
+		}
+		d.pad = v
+	case IbcSAD:
+		if !validSad(v) {
+			return s.fail(d, EARG, 0)
+		}
+		d.sad = v
+	case IbcTMO:
+		if t := Timeout(v); t < TNONE || t > T1000s {
+			return s.fail(d, EARG, 0)
+		}
+		d.tmo = Timeout(v)
+	case IbcEOT:
+		d.eot = v
+	case IbcEOSrd, IbcEOSwrt, IbcEOScmp, IbcEOSchar:
+		s.eos(d, option, v)
+	case IbcSC:
+		d.board.sc = v != 0
+		if !d.board.sc {
+			d.board.cic = false
+		}
+	case IbcSRE:
+		if !d.board.sc {
+			return s.fail(d, ESAC, 0)
+		}
+		d.board.ren = v != 0
+	case IbcIst, IbcRsv, IbcLON:
+		if d.dev {
+			return s.fail(d, ECAP, 0)
+		}
+		d.opts[option] = v
+	default:
+		d.opts[option] = v
+	}
+	// On success iberr holds the previous value of the option.
+	return s.done(d, CMPL, ErrorCode(prev), 0)
+}
+
+func (s *Sim) Ibdev(boardID, pad, sad int, tmo Timeout, eot, eos int) (int, Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.boards[boardID]
+	switch {
+	case b == nil:
+		s.fail(nil, ENEB, 0)
+		return -1, s.last()
+	case !validPad(pad) || !validSad(sad) || tmo < TNONE || tmo > T1000s:
+		s.fail(nil, EARG, 0)
+		return -1, s.last()
+	}
+	ud := s.next
+	s.next++
+	d := &simDesc{board: b, dev: true, pad: pad, sad: sad, tmo: tmo,
+		eot: eot, eos: eos, opts: make(map[ConfigOption]int)}
+	s.descs[ud] = d
+	s.ok(d, 0)
+	return ud, s.last()
+}
+
+// Ibdiag, Ibexpert and Ibpoke reach into the hardware and the driver,
+// which the simulator does not have.
+func (s *Sim) Ibdiag(ud int, buf []byte) Result {
+	return s.noCap(ud)
+}
+
+func (s *Sim) noCap(ud int) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.desc(ud)
+	if d == nil {
+		return s.last()
+	}
+	return s.fail(d, ECAP, 0)
+}
+
+func (s *Sim) Ibeos(ud, v int) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.desc(ud)
+	if d == nil {
+		return s.last()
+	}
+	prev := d.eos
+	d.eos = v
+	return s.done(d, CMPL, ErrorCode(prev), 0)
+}
+
+func (s *Sim) Ibexpert(ud, option int, input, output []byte) Result {
+	return s.noCap(ud)
+}
+
+func (s *Sim) Ibfind(udname string) (int, Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i, ok := boardIndex(udname); ok && s.boards[i] != nil {
+		b := s.boards[i]
+		s.descs[i] = &b.cfg
+		s.ok(&b.cfg, 0)
+		return i, s.last()
+	}
+	s.fail(nil, EDVR, 0)
+	return -1, s.last()
+}
+
+// boardIndex returns the index of the board named udname, e.g. "GPIB0".
+func boardIndex(udname string) (int, bool) {
+	name := strings.ToUpper(udname)
+	if !strings.HasPrefix(name, "GPIB") {
+		return 0, false
+	}
+	i, err := strconv.Atoi(name[4:])
+	return i, err == nil && i >= 0
+}
+
+func (s *Sim) Ibgts(ud, v int) Result {
+	return s.Ibcac(ud, v)
+}
+
+// Iblck gets the lock unless it is held by another process, see HoldLock.
+func (s *Sim) Iblck(ud, v int, lockWaitTime uint) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.desc(ud)
+	if d == nil {
+		return s.last()
+	}
+	if v == 0 {
+		return s.ok(d, 0)
+	}
+	deadline := time.Now().Add(time.Duration(lockWaitTime) * time.Millisecond)
+	for d.board.held {
+		if !time.Now().Before(deadline) {
+			return s.fail(d, ELCK, 0)
+		}
+		s.mu.Unlock()
+		time.Sleep(time.Millisecond)
+		s.mu.Lock()
+	}
+	return s.ok(d, 0)
+}
+
+func (s *Sim) Iblines(ud int) (int16, Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.desc(ud)
+	if d == nil {
+		return 0, s.last()
+	}
+	lines := int16(ValidEOI | ValidATN | ValidSRQ | ValidREN | ValidIFC |
+		ValidNRFD | ValidNDAC | ValidDAV)
+	if d.board.srq() {
+		lines |= BusSRQ
+	}
+	if d.board.ren {
+		lines |= BusREN
+	}
+	return lines, s.ok(d, 0)
+}
+
+func (s *Sim) Ibln(ud, pad, sad int) (int16, Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.desc(ud)
+	switch {
+	case d == nil:
+		return 0, s.last()
+	case !validPad(pad) || (sad != ALL_SAD && !validSad(sad)):
+		return 0, s.fail(d, EARG, 0)
+	}
+	for _, sl := range d.board.slots {
+		if sl.pad == pad && (sad == ALL_SAD || sl.sad == sad) {
+			return 1, s.ok(d, 0)
+		}
+	}
+	return 0, s.ok(d, 0)
+}
+
+func (s *Sim) Ibloc(ud int) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.desc(ud)
+	switch {
+	case d == nil:
+		return s.last()
+	case d.dev:
+		return s.devCmd(d, GTL)
+	}
+	return s.ok(d, 0)
+}
+
+func (s *Sim) Iblock(ud int) Result {
+	return s.Iblck(ud, 1, 0)
+}
+
+func (s *Sim) Iblockx(ud, lockWaitTime int, lockShareName string) Result {
+	return s.Iblck(ud, 1, uint(lockWaitTime))
+}
+
+// Ibnotify calls f from its own goroutine whenever one of the events in
+// mask is true, as Ibwait would report them, until f returns 0, the
+// descriptor goes offline or Ibnotify is called again.
+func (s *Sim) Ibnotify(ud int, mask Status, f NotifyFunc) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.desc(ud)
+	switch {
+	case d == nil:
+		return s.last()
+	case !validWait(d, mask):
+		return s.fail(d, EARG, 0)
+	}
+	d.notify++
+	if mask != 0 {
+		go s.notifyLoop(ud, d, d.notify, mask, f)
+	}
+	return s.ok(d, 0)
+}
+
+func (s *Sim) notifyLoop(ud int, d *simDesc, gen int, mask Status, f NotifyFunc) {
+	var deadline time.Time
+	for {
+		s.mu.Lock()
+		if s.descs[ud] != d || d.notify != gen {
+			s.mu.Unlock()
+			return
+		}
+		if deadline.IsZero() && mask&TIMO != 0 && d.tmo > TNONE {
+			deadline = time.Now().Add(timeoutDurations[d.tmo])
+		}
+		sta := s.status(d)
+		fire := sta&(mask&^TIMO) != 0
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			sta |= TIMO
+			fire = true
+		}
+		s.mu.Unlock()
+		if !fire {
+			time.Sleep(time.Millisecond)
+			continue
+		}
+		deadline = time.Time{}
+		if mask = f(ud, Status(sta), 0, 0); mask == 0 {
+			return
+		}
+		s.mu.Lock()
+		ok := validWait(d, mask)
+		s.mu.Unlock()
+		if !ok {
+			f(ud, Status(sta|ERR), EARM, 0)
+			return
+		}
+	}
+}
+
+func (s *Sim) Ibonl(ud, v int) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.desc(ud)
+	if d == nil {
+		return s.last()
+	}
+	d.abort()
+	sta := s.ok(d, 0)
+	if v == 0 {
+		delete(s.descs, ud)
+	}
+	return sta
+}
+
+func (s *Sim) Ibpct(ud int) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.desc(ud)
+	switch {
+	case d == nil:
+		return s.last()
+	case !d.dev:
+		return s.fail(d, EARG, 0)
+	case !d.board.cic:
+		return s.fail(d, ECIC, 0)
+	}
+	d.board.talk(MakeAddr(d.pad, d.sad))
+	d.board.command([]byte{byte(TCT)})
+	return s.ok(d, 0)
+}
+
+func (s *Sim) Ibpoke(ud, option, v int) Result {
+	return s.noCap(ud)
+}
+
+func (s *Sim) Ibppc(ud, v int) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.desc(ud)
+	switch {
+	case d == nil:
+		return s.last()
+	case v != 0 && (v < int(PPE) || v > int(PPD)):
+		return s.fail(d, EARG, 0)
+	case !d.dev:
+		prev := d.opts[IbcPPC]
+		d.opts[IbcPPC] = v
+		return s.done(d, CMPL, ErrorCode(prev), 0)
+	case !d.board.cic:
+		return s.fail(d, ECIC, 0)
+	}
+	prev := 0
+	if sl := d.board.slots[MakeAddr(d.pad, d.sad)]; sl != nil {
+		prev = sl.ppr
+	}
+	if v == 0 {
+		v = int(PPD)
+	}
+	d.board.listen(MakeAddr(d.pad, d.sad))
+	d.board.command([]byte{byte(PPC), byte(v)})
+	return s.done(d, CMPL, ErrorCode(prev), 0)
+}
+
+func (s *Sim) Ibrd(ud int, buf []byte) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.desc(ud)
+	if d == nil {
+		return s.last()
+	}
+	return s.read(d, buf)
+}
+
+// Ibrda reads synchronously, the simulator completes asynchronous I/O
+// before returning.
+func (s *Sim) Ibrda(ud int, buf []byte) Result {
+	return s.async(ud, s.Ibrd(ud, buf))
+}
+
+func (s *Sim) Ibrdf(ud int, filename string) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.desc(ud)
+	if d == nil {
+		return s.last()
+	}
+	var data []byte
+	buf := make([]byte, 4096)
+	for {
+		r := s.read(d, buf)
+		data = append(data, buf[:r.Ibcntl]...)
+		if r.Ibsta&ERR != 0 {
+			return s.done(d, r.Ibsta, r.Iberr, len(data))
+		}
+		if r.Ibsta&END != 0 {
+			break
+		}
+	}
+	if err := ioutil.WriteFile(filename, data, 0666); err != nil {
+		return s.fail(d, EFSO, len(data))
+	}
+	return s.done(d, END|CMPL, 0, len(data))
+}
+
+func (s *Sim) Ibrpp(ud int) (byte, Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.desc(ud)
+	switch {
+	case d == nil:
+		return 0, s.last()
+	case !d.board.cic:
+		return 0, s.fail(d, ECIC, 0)
+	}
+	return d.board.ppoll(), s.ok(d, 0)
+}
+
+func (s *Sim) Ibrsp(ud int) (byte, Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.desc(ud)
+	switch {
+	case d == nil:
+		return 0, s.last()
+	case !d.dev:
+		return 0, s.fail(d, EARG, 0)
+	case !d.board.cic:
+		return 0, s.fail(d, ECIC, 0)
+	}
+	spr, ok := s.spoll(d, MakeAddr(d.pad, d.sad))
+	if !ok {
+		return 0, s.timedOut(d, 0)
+	}
+	return spr, s.done(d, s.status(d), 0, 1)
+}
+
+func (s *Sim) Ibsic(ud int) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.desc(ud)
+	switch {
+	case d == nil:
+		return s.last()
+	case d.dev:
+		return s.fail(d, EARG, 0)
+	case !d.board.sc:
+		return s.fail(d, ESAC, 0)
+	}
+	d.board.cic = true
+	d.board.unaddress()
+	return s.ok(d, 0)
+}
+
+// Ibstop aborts the calls blocked on ud, which end with EABO. The
+// simulator completes asynchronous I/O before returning, so there is none
+// to stop.
+func (s *Sim) Ibstop(ud int) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.desc(ud)
+	if d == nil {
+		return s.last()
+	}
+	d.abort()
+	return s.ok(d, 0)
+}
+
+func (s *Sim) Ibtrg(ud int) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.desc(ud)
+	switch {
+	case d == nil:
+		return s.last()
+	case !d.dev:
+		return s.fail(d, EARG, 0)
+	}
+	return s.devCmd(d, GET)
+}
+
+func (s *Sim) Ibunlock(ud int) Result {
+	return s.Iblck(ud, 0, 0)
+}
+
+func (s *Sim) Ibunlockx(ud int) Result {
+	return s.Iblck(ud, 0, 0)
+}
+
+const simWaitMask = TIMO | END | SRQI | RQS | CMPL | LOK | REM | CIC |
+	ATN | TACS | LACS | DTAS | DCAS
+
+// validWait reports whether mask is a valid Ibwait mask for d.
+func validWait(d *simDesc, mask Status) bool {
+	return mask&^simWaitMask == 0 &&
+		!(d.dev && mask&SRQI != 0) &&
+		!(!d.dev && mask&RQS != 0)
+}
+
+func (s *Sim) Ibwait(ud int, mask Status) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.desc(ud)
+	switch {
+	case d == nil:
+		return s.last()
+	case !validWait(d, mask):
+		return s.fail(d, EARG, 0)
+	}
+	var deadline time.Time
+	if mask&TIMO != 0 && d.tmo > TNONE {
+		deadline = time.Now().Add(timeoutDurations[d.tmo])
+	}
+	stop := d.stopper()
+	for {
+		select {
+		case <-stop:
+			return s.fail(d, EABO, 0)
+		default:
+		}
+		sta := s.status(d)
+		if mask == 0 || sta&(mask&^TIMO) != 0 {
+			cntl := d.async
+			d.async = 0
+			return s.done(d, sta, 0, cntl)
+		}
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			return s.done(d, sta|TIMO, 0, 0)
+		}
+		s.mu.Unlock()
+		time.Sleep(time.Millisecond)
+		s.mu.Lock()
+	}
+}
+
+func (s *Sim) Ibwrt(ud int, buf []byte) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.desc(ud)
+	if d == nil {
+		return s.last()
+	}
+	return s.write(d, buf)
+}
+
+func (s *Sim) Ibwrta(ud int, buf []byte) Result {
+	return s.async(ud, s.Ibwrt(ud, buf))
+}
+
+// async records r as the result of an asynchronous I/O on ud, so that the
+// Ibwait reporting its completion has its ibcntl.
+func (s *Sim) async(ud int, r Result) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if d := s.descs[ud]; d != nil && r.Ibsta&ERR == 0 {
+		d.async = r.Ibcntl
+	}
+	return r
+}
+
+func (s *Sim) Ibwrtf(ud int, filename string) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.desc(ud)
+	if d == nil {
+		return s.last()
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return s.fail(d, EFSO, 0)
+	}
+	return s.write(d, data)
+}
+
+// The simulator keeps a single copy of the status, Ibsta, Iberr and Ibcntl
+// are the same as ThreadIbsta, ThreadIberr and ThreadIbcntl.
+func (s *Sim) Ibsta() uint32 {
+	return s.ThreadIbsta()
+}
+
+func (s *Sim) Iberr() uint32 {
+	return s.ThreadIberr()
+}
+
+func (s *Sim) Ibcntl() uint32 {
+	return s.ThreadIbcntl()
+}
+
+func (s *Sim) ThreadIbsta() uint32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sta
+}
+
+func (s *Sim) ThreadIberr() uint32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *Sim) ThreadIbcntl() uint32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cntl
+}
+
+//  NI-488.2 Functions
+
+// controller looks up boardID for a routine that needs the board to be
+// Controller-In-Charge.
+func (s *Sim) controller(boardID int) *simDesc {
+	d := s.boardDesc(boardID)
+	if d != nil && !d.board.cic {
+		s.fail(d, ECIC, 0)
+		return nil
+	}
+	return d
+}
+
+// validAddrs records EARG unless every address in addrs is valid.
+func (s *Sim) validAddrs(d *simDesc, addrs ...Address) bool {
+	for _, a := range addrs {
+		if !a.Valid() {
+			s.fail(d, EARG, 0)
+			return false
+		}
+	}
+	return true
+}
+
+// addrCmd sends cmd to the devices at addrs, or the universal command
+// all if addrs is empty.
+func (s *Sim) addrCmd(boardID int, addrs []Address, cmd, all Command) {
+	d := s.controller(boardID)
+	if d == nil || !s.validAddrs(d, addrs...) {
+		return
+	}
+	if len(d.board.slots) == 0 {
+		s.fail(d, ENOL, 0)
+		return
+	}
+	if len(addrs) == 0 {
+		d.board.command([]byte{byte(all)})
+		s.ok(d, 1)
+		return
+	}
+	d.board.listen(addrs...)
+	d.board.command([]byte{byte(cmd)})
+	s.ok(d, len(addrs)+3)
+}
+
+func (s *Sim) AllSpoll(boardID int, addrlist []Address, results []int16) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.controller(boardID)
+	if d == nil || !s.validAddrs(d, addrlist...) {
+		return s.last()
+	}
+	for i, a := range addrlist {
+		spr, ok := s.spoll(d, a)
+		if !ok {
+			s.timedOut(d, i)
+			return s.last()
+		}
+		if i < len(results) {
+			results[i] = int16(spr)
+		}
+	}
+	s.ok(d, len(addrlist))
+	return s.last()
+}
+
+func (s *Sim) DevClear(boardID int, addr Address) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if addr == NOADDR {
+		s.addrCmd(boardID, nil, SDC, DCL)
+		return s.last()
+	}
+	s.addrCmd(boardID, []Address{addr}, SDC, DCL)
+	return s.last()
+}
+
+func (s *Sim) DevClearList(boardID int, addrlist []Address) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addrCmd(boardID, addrlist, SDC, DCL)
+	return s.last()
+}
+
+func (s *Sim) EnableLocal(boardID int, addrlist []Address) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(addrlist) == 0 {
+		d := s.boardDesc(boardID)
+		if d == nil {
+			return s.last()
+		}
+		if !d.board.sc {
+			s.fail(d, ESAC, 0)
+			return s.last()
+		}
+		d.board.ren, d.board.llo = false, false
+		s.ok(d, 0)
+		return s.last()
+	}
+	s.addrCmd(boardID, addrlist, GTL, GTL)
+	return s.last()
+}
+
+func (s *Sim) EnableRemote(boardID int, addrlist []Address) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.boardDesc(boardID)
+	if d == nil {
+		return s.last()
+	}
+	if !d.board.sc {
+		s.fail(d, ESAC, 0)
+		return s.last()
+	}
+	d.board.ren = true
+	if len(addrlist) == 0 {
+		s.ok(d, 0)
+		return s.last()
+	}
+	if !s.validAddrs(d, addrlist...) {
+		return s.last()
+	}
+	d.board.listen(addrlist...)
+	s.ok(d, len(addrlist)+2)
+	return s.last()
+}
+
+func (s *Sim) FindLstn(boardID int, addrlist, results []Address) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.controller(boardID)
+	if d == nil {
+		return s.last()
+	}
+	n := 0
+	for _, a := range addrlist {
+		pad := a.Primary()
+		if !a.Valid() || a.Secondary() != NO_SAD {
+			s.fail(d, EARG, n)
+			return s.last()
+		}
+		var found []Address
+		if d.board.slots[MakeAddr(pad, 0)] != nil {
+			found = append(found, Address(pad))
+		} else {
+			for sad := 0x60; sad <= 0x7E; sad++ {
+				if d.board.slots[MakeAddr(pad, sad)] != nil {
+					found = append(found, MakeAddr(pad, sad))
+				}
+			}
+		}
+		for _, f := range found {
+			if n == len(results) {
+				s.fail(d, ETAB, n)
+				return s.last()
+			}
+			results[n] = f
+			n++
+		}
+	}
+	d.board.unaddress()
+	s.ok(d, n)
+	return s.last()
+}
+
+func (s *Sim) FindRQS(boardID int, addrlist []Address) (int16, Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.controller(boardID)
+	if d == nil || !s.validAddrs(d, addrlist...) {
+		return 0, s.last()
+	}
+	for i, a := range addrlist {
+		spr, ok := s.spoll(d, a)
+		if !ok {
+			s.timedOut(d, i)
+			return 0, s.last()
+		}
+		if spr&simRQS != 0 {
+			s.ok(d, i)
+			return int16(spr), s.last()
+		}
+	}
+	s.fail(d, ETAB, len(addrlist))
+	return 0, s.last()
+}
+
+func (s *Sim) PPoll(boardID int) (int16, Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.controller(boardID)
+	if d == nil {
+		return 0, s.last()
+	}
+	ppr := d.board.ppoll()
+	s.ok(d, 0)
+	return int16(ppr), s.last()
+}
+
+func (s *Sim) PPollConfig(boardID int, addr Address, dataLine, lineSense int) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.controller(boardID)
+	if d == nil || !s.validAddrs(d, addr) {
+		return s.last()
+	}
+	if dataLine < 1 || dataLine > 8 || (lineSense != 0 && lineSense != 1) {
+		s.fail(d, EARG, 0)
+		return s.last()
+	}
+	d.board.listen(addr)
+	d.board.command([]byte{byte(PPC), byte(int(PPE) | lineSense<<3 | (dataLine - 1))})
+	s.ok(d, 0)
+	return s.last()
+}
+
+func (s *Sim) PPollUnconfig(boardID int, addrlist []Address) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.controller(boardID)
+	if d == nil || !s.validAddrs(d, addrlist...) {
+		return s.last()
+	}
+	if len(addrlist) == 0 {
+		d.board.command([]byte{byte(PPU)})
+	} else {
+		d.board.listen(addrlist...)
+		d.board.command([]byte{byte(PPC), byte(PPD)})
+	}
+	s.ok(d, 0)
+	return s.last()
+}
+
+func (s *Sim) PassControl(boardID int, addr Address) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.controller(boardID)
+	if d == nil || !s.validAddrs(d, addr) {
+		return s.last()
+	}
+	d.board.talk(addr)
+	d.board.command([]byte{byte(TCT)})
+	s.ok(d, 0)
+	return s.last()
+}
+
+// termination converts a 488.2 Receive termination to an EOS setting.
+func termination(t int) int {
+	if t == STOPend {
+		return 0
+	}
+	return REOS | BIN | t&0xFF
+}
+
+func (s *Sim) RcvRespMsg(boardID int, buf []byte, term int) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.boardDesc(boardID)
+	if d == nil {
+		return s.last()
+	}
+	return s.rcvRespMsg(d, buf, term)
+}
+
+// rcvRespMsg reads from the talker addressed on the board of d.
+func (s *Sim) rcvRespMsg(d *simDesc, buf []byte, term int) Result {
+	n, end, ok := s.receive(d, buf, d.tmo, termination(term))
+	switch {
+	case !ok:
+		s.timedOut(d, n)
+	case end:
+		s.done(d, END|CMPL, 0, n)
+	default:
+		s.ok(d, n)
+	}
+	return s.last()
+}
+
+func (s *Sim) ReadStatusByte(boardID int, addr Address) (int16, Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.controller(boardID)
+	if d == nil || !s.validAddrs(d, addr) {
+		return 0, s.last()
+	}
+	spr, ok := s.spoll(d, addr)
+	if !ok {
+		s.timedOut(d, 0)
+		return 0, s.last()
+	}
+	s.ok(d, 1)
+	return int16(spr), s.last()
+}
+
+func (s *Sim) Receive(boardID int, addr Address, buf []byte, term int) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.controller(boardID)
+	if d == nil || !s.validAddrs(d, addr) {
+		return s.last()
+	}
+	d.board.talk(addr)
+	return s.rcvRespMsg(d, buf, term)
+}
+
+func (s *Sim) ReceiveSetup(boardID int, addr Address) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.controller(boardID)
+	if d == nil || !s.validAddrs(d, addr) {
+		return s.last()
+	}
+	d.board.talk(addr)
+	s.ok(d, 3)
+	return s.last()
+}
+
+func (s *Sim) ResetSys(boardID int, addrlist []Address) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.boardDesc(boardID)
+	if d == nil || !s.validAddrs(d, addrlist...) {
+		return s.last()
+	}
+	b := d.board
+	if !b.sc {
+		s.fail(d, ESAC, 0)
+		return s.last()
+	}
+	b.ren, b.cic = true, true
+	b.unaddress()
+	b.command([]byte{byte(DCL)})
+	for _, a := range addrlist {
+		b.listen(a)
+		if !b.sendData([]byte("*RST\n"), true) {
+			s.fail(d, ENOL, 0)
+			return s.last()
+		}
+	}
+	s.ok(d, 0)
+	return s.last()
+}
+
+// sendData sends data to the current listeners with the 488.2 eotMode.
+func (s *Sim) sendData(d *simDesc, data []byte, eotMode int) {
+	if eotMode == NLend {
+		data = append(data[:len(data):len(data)], '\n')
+	}
+	if !d.board.sendData(data, eotMode != NULLend) {
+		s.fail(d, ENOL, 0)
+		return
+	}
+	s.ok(d, len(data))
+}
+
+func (s *Sim) Send(boardID int, addr Address, data []byte, eotMode int) Result {
+	return s.SendList(boardID, []Address{addr}, data, eotMode)
+}
+
+func (s *Sim) SendCmds(boardID int, cmds []byte) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.boardDesc(boardID)
+	if d == nil {
+		return s.last()
+	}
+	s.cmd(d, cmds)
+	return s.last()
+}
+
+func (s *Sim) SendDataBytes(boardID int, data []byte, eotMode int) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.boardDesc(boardID)
+	if d == nil {
+		return s.last()
+	}
+	s.sendData(d, data, eotMode)
+	return s.last()
+}
+
+func (s *Sim) SendIFC(boardID int) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.boardDesc(boardID)
+	if d == nil {
+		return s.last()
+	}
+	if !d.board.sc {
+		s.fail(d, ESAC, 0)
+		return s.last()
+	}
+	d.board.cic = true
+	d.board.unaddress()
+	s.ok(d, 0)
+	return s.last()
+}
+
+func (s *Sim) SendLLO(boardID int) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.controller(boardID)
+	if d == nil {
+		return s.last()
+	}
+	d.board.command([]byte{byte(LLO)})
+	s.ok(d, 1)
+	return s.last()
+}
+
+func (s *Sim) SendList(boardID int, addrlist []Address, data []byte, eotMode int) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.controller(boardID)
+	if d == nil || !s.validAddrs(d, addrlist...) {
+		return s.last()
+	}
+	d.board.listen(addrlist...)
+	s.sendData(d, data, eotMode)
+	return s.last()
+}
+
+func (s *Sim) SendSetup(boardID int, addrlist []Address) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.controller(boardID)
+	if d == nil || !s.validAddrs(d, addrlist...) {
+		return s.last()
+	}
+	d.board.listen(addrlist...)
+	s.ok(d, len(addrlist)+2)
+	return s.last()
+}
+
+func (s *Sim) SetRWLS(boardID int, addrlist []Address) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.controller(boardID)
+	if d == nil || !s.validAddrs(d, addrlist...) {
+		return s.last()
+	}
+	if !d.board.sc {
+		s.fail(d, ESAC, 0)
+		return s.last()
+	}
+	d.board.ren = true
+	d.board.listen(addrlist...)
+	d.board.command([]byte{byte(LLO)})
+	s.ok(d, len(addrlist)+3)
+	return s.last()
+}
+
+func (s *Sim) TestSRQ(boardID int) (int16, Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.boardDesc(boardID)
+	if d == nil {
+		return 0, s.last()
+	}
+	s.ok(d, 0)
+	if d.board.srq() {
+		return 1, s.last()
+	}
+	return 0, s.last()
+}
+
+func (s *Sim) TestSys(boardID int, addrlist []Address, results []int16) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.controller(boardID)
+	if d == nil || !s.validAddrs(d, addrlist...) {
+		return s.last()
+	}
+	failed, aborted := 0, false
+	buf := make([]byte, 64)
+	for i, a := range addrlist {
+		d.board.listen(a)
+		if !d.board.sendData([]byte("*TST?\n"), true) {
+			s.fail(d, ENOL, i)
+			return s.last()
+		}
+		d.board.talk(a)
+		n, _, ok := s.receive(d, buf, d.tmo, 0)
+		v, err := strconv.Atoi(strings.TrimSpace(string(buf[:n])))
+		switch {
+		case !ok:
+			v, aborted = 1, true
+		case err != nil:
+			v = 1
+		}
+		if v != 0 {
+			failed++
+		}
+		if i < len(results) {
+			results[i] = int16(v)
+		}
+	}
+	d.board.unaddress()
+	if aborted {
+		s.fail(d, EABO, failed)
+		return s.last()
+	}
+	s.ok(d, failed)
+	return s.last()
+}
+
+func (s *Sim) Trigger(boardID int, addr Address) Result {
+	if addr == NOADDR {
+		return s.TriggerList(boardID, nil)
+	}
+	return s.TriggerList(boardID, []Address{addr})
+}
+
+func (s *Sim) TriggerList(boardID int, addrlist []Address) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.controller(boardID)
+	if d == nil || !s.validAddrs(d, addrlist...) {
+		return s.last()
+	}
+	if len(addrlist) != 0 {
+		d.board.listen(addrlist...)
+	}
+	d.board.command([]byte{byte(GET)})
+	s.ok(d, len(addrlist)+1)
+	return s.last()
+}
+
+func (s *Sim) WaitSRQ(boardID int) (int16, Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.boardDesc(boardID)
+	if d == nil {
+		return 0, s.last()
+	}
+	var deadline time.Time
+	if d.tmo > TNONE {
+		deadline = time.Now().Add(timeoutDurations[d.tmo])
+	}
+	stop := d.stopper()
+	for !d.board.srq() {
+		select {
+		case <-stop:
+			s.fail(d, EABO, 0)
+			return 0, s.last()
+		default:
+		}
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			s.done(d, TIMO|CMPL, 0, 0)
+			return 0, s.last()
+		}
+		s.mu.Unlock()
+		time.Sleep(time.Millisecond)
+		s.mu.Lock()
+	}
+	s.ok(d, 0)
+	return 1, s.last()
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SimInstrument is a SimDevice that passes each message it receives to a
+// handler and queues the handler's reply for the next read. A message ends
+// with a newline or with a byte sent with EOI.
+type SimInstrument struct {
+	handler func(msg string) []byte
+
+	// OnClear and OnTrigger, when set, are called on a device clear and
+	// a group execute trigger.
+	OnClear   func()
+	OnTrigger func()
+
+	mu    sync.Mutex
+	in    []byte
+	out   [][]byte
+	stb   byte
+	gen   int
+	ready chan struct{}
+}
+
+// NewSimInstrument returns an instrument that answers messages with
+// handler. The message passed to handler has its terminator and any
+// surrounding white space removed; a nil reply means there is nothing to
+// read back.
+func NewSimInstrument(handler func(msg string) []byte) *SimInstrument {
+	return &SimInstrument{handler: handler, ready: make(chan struct{})}
+}
+
+// Queue adds resp to the messages waiting to be read from the instrument.
+func (i *SimInstrument) Queue(resp []byte) {
+	i.mu.Lock()
+	i.queue(resp)
+	i.mu.Unlock()
+}
+
+// QueueAfter adds resp to the messages waiting to be read once d has
+// passed. A device clear drops replies that are still on their way.
+func (i *SimInstrument) QueueAfter(resp []byte, d time.Duration) {
+	if d <= 0 {
+		i.Queue(resp)
+		return
+	}
+	i.mu.Lock()
+	gen := i.gen
+	i.mu.Unlock()
+	time.AfterFunc(d, func() {
+		i.mu.Lock()
+		if i.gen == gen {
+			i.queue(resp)
+		}
+		i.mu.Unlock()
+	})
+}
+
+func (i *SimInstrument) queue(resp []byte) {
+	i.out = append(i.out, resp)
+	close(i.ready)
+	i.ready = make(chan struct{})
+}
+
+// SetStatusByte sets the serial poll status byte. Setting bit 6 (0x40)
+// asserts SRQ until the instrument is serial polled.
+func (i *SimInstrument) SetStatusByte(stb byte) {
+	i.mu.Lock()
+	i.stb = stb
+	i.mu.Unlock()
+}
+
+// update replaces the status byte with f of its current value.
+func (i *SimInstrument) update(f func(stb byte) byte) {
+	i.mu.Lock()
+	i.stb = f(i.stb)
+	i.mu.Unlock()
+}
+
+// RequestService sets the status byte to stb and requests service.
+func (i *SimInstrument) RequestService(stb byte) {
+	i.SetStatusByte(stb | simRQS)
+}
+
+func (i *SimInstrument) Listen(data []byte, end bool) {
+	i.mu.Lock()
+	i.in = append(i.in, data...)
+	var msgs []string
+	for {
+		n := bytes.IndexByte(i.in, '\n')
+		if n < 0 {
+			break
+		}
+		msgs = append(msgs, string(i.in[:n]))
+		i.in = i.in[n+1:]
+	}
+	if end && len(i.in) != 0 {
+		msgs = append(msgs, string(i.in))
+		i.in = nil
+	}
+	i.mu.Unlock()
+
+	for _, m := range msgs {
+		if i.handler == nil {
+			continue
+		}
+		if resp := i.handler(strings.TrimSpace(m)); resp != nil {
+			i.Queue(resp)
+		}
+	}
+}
+
+func (i *SimInstrument) Talk(ctx context.Context) ([]byte, error) {
+	for {
+		i.mu.Lock()
+		if len(i.out) != 0 {
+			resp := i.out[0]
+			i.out = i.out[1:]
+			i.mu.Unlock()
+			return resp, nil
+		}
+		ready := i.ready
+		i.mu.Unlock()
+
+		select {
+		case <-ready:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (i *SimInstrument) Clear() {
+	i.mu.Lock()
+	i.in, i.out = nil, nil
+	i.gen++
+	i.mu.Unlock()
+	if i.OnClear != nil {
+		i.OnClear()
+	}
+}
+
+func (i *SimInstrument) Trigger() {
+	if i.OnTrigger != nil {
+		i.OnTrigger()
+	}
+}
+
+func (i *SimInstrument) StatusByte() byte {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.stb
+}
+
+func (i *SimInstrument) SerialPoll() byte {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	stb := i.stb
+	i.stb &^= simRQS
+	return stb
+}
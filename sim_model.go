@@ -0,0 +1,276 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SimModel declares a scripted instrument for the simulated bus. Models
+// are usually kept in JSON files, see ReadSimModels and the models
+// directory.
+//
+// A message is split into commands at semicolons. Each command is tried
+// against Rules in order and then against the common commands *IDN?,
+// *RST, *CLS, *TST?, *OPC? and *STB?. The replies to the queries of a
+// message are joined with semicolons and terminated with a newline.
+type SimModel struct {
+	Name  string `json:"name"`
+	Board int    `json:"board"`
+	Pad   int    `json:"pad"`
+	Sad   int    `json:"sad,omitempty"`
+
+	// IDN is the reply to *IDN?.
+	IDN string `json:"idn"`
+
+	Rules []SimRule `json:"rules,omitempty"`
+
+	// Default is the reply to a query no rule matches. When it is empty
+	// such a query has no reply.
+	Default string `json:"default,omitempty"`
+
+	// Delay is how long every reply takes to become readable, e.g. "20ms".
+	Delay string `json:"delay,omitempty"`
+
+	// ErrorQueue enables the SCPI error queue. Unknown commands queue
+	// -113,"Undefined header", SYSTem:ERRor? returns the oldest error and
+	// bit 2 of the status byte is set while the queue is not empty.
+	ErrorQueue bool `json:"errorQueue,omitempty"`
+}
+
+// SimRule maps a command onto a reply and its side effects.
+type SimRule struct {
+	// Match is compared with the command ignoring case.
+	Match string `json:"match,omitempty"`
+
+	// Regexp must match the whole command. The reply can refer to its
+	// submatches as $1, $2, ...
+	Regexp string `json:"regexp,omitempty"`
+
+	Reply string `json:"reply,omitempty"`
+
+	// Delay replaces the model's delay for this reply.
+	Delay string `json:"delay,omitempty"`
+
+	// Error is added to the error queue, e.g. -222,"Data out of range".
+	Error string `json:"error,omitempty"`
+
+	// STB, when set, becomes the status byte after the command.
+	STB *int `json:"stb,omitempty"`
+
+	// SRQ requests service after the command.
+	SRQ bool `json:"srq,omitempty"`
+}
+
+// ReadSimModels decodes the models in r, which holds either a single JSON
+// object or an array of them.
+func ReadSimModels(r io.Reader) ([]SimModel, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) != 0 && data[0] == '[' {
+		var models []SimModel
+		if err := json.Unmarshal(data, &models); err != nil {
+			return nil, err
+		}
+		return models, nil
+	}
+	var m SimModel
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return []SimModel{m}, nil
+}
+
+// AttachModel builds the instrument described by m and attaches it to the
+// simulated bus at the model's address.
+func (s *Sim) AttachModel(m SimModel) (*SimInstrument, error) {
+	inst, err := m.Instrument()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Attach(m.Board, m.Pad, m.Sad, inst); err != nil {
+		return nil, err
+	}
+	return inst, nil
+}
+
+// Instrument builds the instrument described by m.
+func (m SimModel) Instrument() (*SimInstrument, error) {
+	sm := &simModel{model: m}
+	var err error
+	if sm.delay, err = parseSimDelay(m.Delay); err != nil {
+		return nil, fmt.Errorf("ni488: model %s: %v", m.Name, err)
+	}
+	for i, r := range m.Rules {
+		cr := simRule{SimRule: r, delay: -1}
+		switch {
+		case r.Regexp != "":
+			if cr.re, err = regexp.Compile("^(?:" + r.Regexp + ")$"); err != nil {
+				return nil, fmt.Errorf("ni488: model %s rule %d: %v", m.Name, i, err)
+			}
+		case r.Match == "":
+			return nil, fmt.Errorf("ni488: model %s rule %d: no match or regexp", m.Name, i)
+		}
+		if r.Delay != "" {
+			if cr.delay, err = parseSimDelay(r.Delay); err != nil {
+				return nil, fmt.Errorf("ni488: model %s rule %d: %v", m.Name, i, err)
+			}
+		}
+		sm.rules = append(sm.rules, cr)
+	}
+	sm.inst = NewSimInstrument(sm.handle)
+	return sm.inst, nil
+}
+
+func parseSimDelay(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
+
+type simRule struct {
+	SimRule
+	re    *regexp.Regexp
+	delay time.Duration
+}
+
+type simModel struct {
+	model SimModel
+	rules []simRule
+	delay time.Duration
+	inst  *SimInstrument
+
+	mu   sync.Mutex
+	errs []string
+}
+
+// simESB is the status byte bit set while the error queue is not empty.
+const simESB = 0x04
+
+var simSystErr = regexp.MustCompile(`(?i)^SYST(EM)?:ERR(OR)?(:NEXT)?\?$`)
+
+func (sm *simModel) handle(msg string) []byte {
+	var replies []string
+	var delay time.Duration
+	for _, cmd := range strings.Split(msg, ";") {
+		cmd = strings.TrimSpace(cmd)
+		if cmd == "" {
+			continue
+		}
+		reply, d, ok := sm.command(cmd)
+		if !ok {
+			continue
+		}
+		replies = append(replies, reply)
+		if d < 0 {
+			d = sm.delay
+		}
+		if d > delay {
+			delay = d
+		}
+	}
+	if len(replies) != 0 {
+		sm.inst.QueueAfter([]byte(strings.Join(replies, ";")+"\n"), delay)
+	}
+	return nil
+}
+
+// command executes cmd and returns its reply, if any. A negative delay
+// stands for the model's delay.
+func (sm *simModel) command(cmd string) (reply string, delay time.Duration, ok bool) {
+	for _, r := range sm.rules {
+		if r.re != nil {
+			m := r.re.FindStringSubmatchIndex(cmd)
+			if m == nil {
+				continue
+			}
+			reply = string(r.re.ExpandString(nil, r.Reply, cmd, m))
+		} else if strings.EqualFold(cmd, r.Match) {
+			reply = r.Reply
+		} else {
+			continue
+		}
+		if r.Error != "" {
+			sm.pushError(r.Error)
+		}
+		if r.STB != nil {
+			sm.inst.update(func(stb byte) byte {
+				return byte(*r.STB) | stb&simESB
+			})
+		}
+		if r.SRQ {
+			sm.inst.update(func(stb byte) byte { return stb | simRQS })
+		}
+		return reply, r.delay, reply != ""
+	}
+
+	switch up := strings.ToUpper(cmd); {
+	case up == "*IDN?":
+		return sm.model.IDN, -1, true
+	case up == "*RST":
+		return "", -1, false
+	case up == "*CLS":
+		sm.clear()
+		return "", -1, false
+	case up == "*TST?":
+		return "0", -1, true
+	case up == "*OPC?":
+		return "1", -1, true
+	case up == "*STB?":
+		return strconv.Itoa(int(sm.inst.StatusByte())), -1, true
+	case sm.model.ErrorQueue && simSystErr.MatchString(cmd):
+		return sm.popError(), -1, true
+	}
+
+	if sm.model.ErrorQueue {
+		sm.pushError(`-113,"Undefined header"`)
+	}
+	if strings.HasSuffix(cmd, "?") && sm.model.Default != "" {
+		return sm.model.Default, -1, true
+	}
+	return "", -1, false
+}
+
+func (sm *simModel) pushError(e string) {
+	sm.mu.Lock()
+	sm.errs = append(sm.errs, e)
+	sm.mu.Unlock()
+	sm.inst.update(func(stb byte) byte { return stb | simESB })
+}
+
+func (sm *simModel) popError() string {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if len(sm.errs) == 0 {
+		return `0,"No error"`
+	}
+	e := sm.errs[0]
+	sm.errs = sm.errs[1:]
+	if len(sm.errs) == 0 {
+		sm.inst.update(func(stb byte) byte { return stb &^ simESB })
+	}
+	return e
+}
+
+// clear empties the error queue and the status byte.
+func (sm *simModel) clear() {
+	sm.mu.Lock()
+	sm.errs = nil
+	sm.mu.Unlock()
+	sm.inst.SetStatusByte(0)
+}
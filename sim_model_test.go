@@ -0,0 +1,235 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// attachModels attaches the models in the models directory to s.
+func attachModels(t *testing.T, s *Sim) []SimModel {
+	files, err := filepath.Glob("models/*.json")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no models: %v", err)
+	}
+	var all []SimModel
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		models, err := ReadSimModels(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		for _, m := range models {
+			if _, err := s.AttachModel(m); err != nil {
+				t.Fatalf("%s: %v", file, err)
+			}
+		}
+		all = append(all, models...)
+	}
+	return all
+}
+
+// query sends msg to the device at pad and returns the reply without its
+// newline. A reply that is not read in time is discarded with a device
+// clear.
+func query(t *testing.T, pad int, tmo Timeout, msg string) (string, error) {
+	ud, _, err := Ibdev(0, pad, NO_SAD, tmo, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Ibonl(ud, 0)
+	if _, err := Ibwrt(ud, msg+"\n"); err != nil {
+		t.Fatal(err)
+	}
+	reply, err := ReadMessage(ud, nil)
+	if err != nil {
+		Ibclr(ud)
+	}
+	return strings.TrimSuffix(string(reply), "\n"), err
+}
+
+func TestSimModels(t *testing.T) {
+	s := useSim(t)
+	models := attachModels(t, s)
+	tests := []struct {
+		pad        int
+		msg, reply string
+	}{
+		{1, "*IDN?", "THERMOTRON,8200,0,1.0"},
+		{1, "pvar1?", "+25.0"},
+		{1, "SETP1,30.0;SETP1?", "+25.0"},
+		{1, "BOGUS?", "0"}, // the model's default reply
+		{22, "*IDN?", "HEWLETT-PACKARD,34401A,0,11-5-2"},
+		{22, "MEAS:VOLT:DC?", "+1.23456789E+00"},
+		{22, "CONF:VOLT:DC 10;READ?", "+1.23456789E+00"},
+		{5, "*IDN?;VOLT?", "HEWLETT-PACKARD,E3631A,0,2.1-5.0-1.0;+5.00000000E+00"},
+		{5, "APPL? P6V", `"+5.000000E+00,+1.000000E+00"`},
+		{5, "*TST?;*OPC?", "0;1"},
+	}
+	if len(models) != 3 {
+		t.Errorf("%d models, want 3", len(models))
+	}
+	for _, tt := range tests {
+		if got, err := query(t, tt.pad, T3s, tt.msg); err != nil || got != tt.reply {
+			t.Errorf("pad %d %q = %q, %v; want %q", tt.pad, tt.msg, got, err, tt.reply)
+		}
+	}
+}
+
+// TestSimModelDelays checks that replies take the delay of their rule, or
+// of the model when the rule has none.
+func TestSimModelDelays(t *testing.T) {
+	s := useSim(t)
+	attachModels(t, s)
+	tests := []struct {
+		pad   int
+		msg   string
+		delay time.Duration
+		early Timeout // a timeout that expires before the reply
+	}{
+		{1, "STAT?", 100 * time.Millisecond, T30ms},
+		{22, "MEAS:VOLT?", 150 * time.Millisecond, T100ms},
+		{22, "*IDN?;READ?", 150 * time.Millisecond, T100ms},
+		{5, "MEAS?", 50 * time.Millisecond, T10ms},
+	}
+	for _, tt := range tests {
+		if _, err := query(t, tt.pad, tt.early, tt.msg); !errors.Is(err, ErrTimeout) {
+			t.Errorf("pad %d %q with %v: %v, want a timeout", tt.pad, tt.msg, tt.early, err)
+		}
+		start := time.Now()
+		if _, err := query(t, tt.pad, T3s, tt.msg); err != nil {
+			t.Errorf("pad %d %q: %v", tt.pad, tt.msg, err)
+		}
+		if d := time.Since(start); d < tt.delay {
+			t.Errorf("pad %d %q replied after %v, want %v", tt.pad, tt.msg, d, tt.delay)
+		}
+	}
+}
+
+func TestSimModelErrorQueue(t *testing.T) {
+	s := useSim(t)
+	attachModels(t, s)
+	ud, _, err := Ibdev(0, 5, NO_SAD, T3s, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	esb := func() bool {
+		spr, _, err := Ibrsp(ud)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return spr&0x04 != 0
+	}
+	if esb() {
+		t.Fatal("ESB set with an empty error queue")
+	}
+	Ibwrt(ud, "VOLT 40\n") // out of range
+	Ibwrt(ud, "FOO\n")     // not a command of the model
+	if !esb() {
+		t.Error("ESB not set with errors queued")
+	}
+	for _, want := range []string{`-222,"Data out of range"`, `-113,"Undefined header"`, `0,"No error"`} {
+		if got, _ := query(t, 5, T3s, "SYST:ERR?"); got != want {
+			t.Errorf("SYST:ERR? = %q, want %q", got, want)
+		}
+	}
+	if esb() {
+		t.Error("ESB still set after the queue was read")
+	}
+	Ibwrt(ud, "FOO\n")
+	Ibwrt(ud, "*CLS\n")
+	if got, _ := query(t, 5, T3s, "SYSTEM:ERROR?"); got != `0,"No error"` || esb() {
+		t.Errorf("SYST:ERR? after *CLS = %q", got)
+	}
+}
+
+func TestSimModelStatus(t *testing.T) {
+	s := useSim(t)
+	attachModels(t, s)
+	ud, _, err := Ibdev(0, 22, NO_SAD, T3s, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Ibwrt(ud, "FOO\n") // sets ESB, which the stb of *OPC keeps
+	Ibwrt(ud, "*OPC\n")
+	if srq, _, _ := TestSRQ(0); srq != 1 {
+		t.Error("*OPC did not request service")
+	}
+	if spr, _, _ := Ibrsp(ud); spr != 0x20|0x40|0x04 {
+		t.Errorf("status byte %#x, want 0x64", spr)
+	}
+	if srq, _, _ := TestSRQ(0); srq != 0 {
+		t.Error("SRQ still asserted after the serial poll")
+	}
+}
+
+func TestSimModelRules(t *testing.T) {
+	s := useSim(t)
+	m := SimModel{
+		Name:  "echo",
+		Pad:   9,
+		IDN:   "ACME,ECHO,1,0",
+		Delay: "40ms",
+		Rules: []SimRule{
+			{Regexp: `(?i)ECHO (\w+) (\w+)`, Reply: "$2 $1"},
+			{Match: "fast?", Reply: "now", Delay: "0s"},
+			{Match: "*IDN?", Reply: "overridden"},
+		},
+	}
+	if _, err := s.AttachModel(m); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		msg, reply string
+		tmo        Timeout
+		err        error
+	}{
+		{"echo a b", "b a", T3s, nil},
+		{"ECHO one two;FAST?", "two one;now", T3s, nil},
+		{"*idn?", "overridden", T3s, nil},
+		{"FAST?", "now", T10ms, nil},
+		{"ECHO a b", "", T10ms, ErrTimeout}, // the reply takes 40ms
+		{"ECHO c d", "d c", T1s, nil},
+	}
+	for _, tt := range tests {
+		got, err := query(t, 9, tt.tmo, tt.msg)
+		if got != tt.reply || tt.err == nil && err != nil || tt.err != nil && !errors.Is(err, tt.err) {
+			t.Errorf("%q with %v = %q, %v; want %q, %v", tt.msg, tt.tmo, got, err, tt.reply, tt.err)
+		}
+	}
+
+	bad := []SimModel{
+		{Name: "regexp", Rules: []SimRule{{Regexp: "("}}},
+		{Name: "empty", Rules: []SimRule{{Reply: "x"}}},
+		{Name: "delay", Delay: "soon"},
+	}
+	for _, m := range bad {
+		if _, err := m.Instrument(); err == nil {
+			t.Errorf("model %s built without an error", m.Name)
+		}
+	}
+}
+
+func TestReadSimModels(t *testing.T) {
+	one := `{"name": "a", "pad": 3, "idn": "A"}`
+	for _, in := range []string{one, " [" + one + "]\n"} {
+		models, err := ReadSimModels(strings.NewReader(in))
+		if err != nil || len(models) != 1 || models[0].Pad != 3 || models[0].IDN != "A" {
+			t.Errorf("ReadSimModels(%q) = %+v, %v", in, models, err)
+		}
+	}
+	if _, err := ReadSimModels(strings.NewReader("{")); err == nil {
+		t.Error("no error for bad JSON")
+	}
+}
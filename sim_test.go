@@ -0,0 +1,195 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// useSim installs a new simulator for the rest of the test.
+func useSim(t *testing.T) *Sim {
+	s := NewSim()
+	prev := SetDriver(s)
+	t.Cleanup(func() { SetDriver(prev) })
+	return s
+}
+
+// simDev attaches an instrument at pad 22 of s and opens it with the
+// timeout tmo and the EOS configuration eos.
+func simDev(t *testing.T, s *Sim, tmo Timeout, eos int) (*SimInstrument, int) {
+	inst := NewSimInstrument(nil)
+	if err := s.Attach(0, 22, NO_SAD, inst); err != nil {
+		t.Fatal(err)
+	}
+	ud, _, err := Ibdev(0, 22, NO_SAD, tmo, 1, eos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return inst, ud
+}
+
+func TestSimRead(t *testing.T) {
+	tests := []struct {
+		name   string
+		eos    int
+		queued []string // messages the instrument sends, EOI on the last byte
+		size   int
+		want   string
+		sta    Status // the bits of ibsta checked, ERR, TIMO and END
+		err    error
+	}{
+		{"EOI", 0, []string{"1.5\n"}, 64, "1.5\n", END, nil},
+		{"short buffer", 0, []string{"1.5\n"}, 2, "1.", 0, nil},
+		{"EOS", REOS | '\n', []string{"a\nb"}, 64, "a\n", END, nil},
+		{"EOS ignored", '\n', []string{"a\nb"}, 64, "a\nb", END, nil},
+		{"EOS 7 bit", REOS | '\n', []string{"a\x8ab"}, 64, "a\x8a", END, nil},
+		{"EOS 8 bit", REOS | BIN | '\n', []string{"a\x8ab"}, 64, "a\x8ab", END, nil},
+		{"timeout", 0, nil, 64, "", ERR | TIMO, ErrAborted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := useSim(t)
+			inst, ud := simDev(t, s, T30ms, tt.eos)
+			for _, m := range tt.queued {
+				inst.Queue([]byte(m))
+			}
+			buf := make([]byte, tt.size)
+			r, err := Ibrd(ud, buf)
+			if got := string(buf[:r.count(len(buf))]); got != tt.want {
+				t.Errorf("read %q, want %q", got, tt.want)
+			}
+			if sta := r.Ibsta & (ERR | TIMO | END); sta != tt.sta {
+				t.Errorf("ibsta %v, want %v", r.Ibsta, tt.sta)
+			}
+			if tt.err == nil && err != nil || tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("err %v, want %v", err, tt.err)
+			}
+			if tt.sta&TIMO != 0 && !errors.Is(err, ErrTimeout) {
+				t.Errorf("err %v does not match ErrTimeout", err)
+			}
+		})
+	}
+}
+
+func TestSimNoListeners(t *testing.T) {
+	s := useSim(t)
+	ud, _, err := Ibdev(0, 3, NO_SAD, T30ms, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The bus is empty.
+	r, err := Ibwrt(ud, "x")
+	if !errors.Is(err, ErrNoListeners) || r.Ibsta&TIMO != 0 {
+		t.Errorf("Ibwrt with no device = %v, %v; want ENOL without TIMO", r.Ibsta, err)
+	}
+	// Nothing listens at pad 3.
+	s.Attach(0, 5, NO_SAD, NewSimInstrument(nil))
+	if _, err := Ibwrt(ud, "x"); !errors.Is(err, ErrNoListeners) {
+		t.Errorf("Ibwrt to an absent device = %v, want ENOL", err)
+	}
+}
+
+func TestSimSerialPoll(t *testing.T) {
+	s := useSim(t)
+	inst, ud := simDev(t, s, T30ms, 0)
+	inst.RequestService(0x11)
+	if srq, _, _ := TestSRQ(0); srq != 1 {
+		t.Fatal("SRQ not asserted")
+	}
+	r, _ := Ibwait(ud, 0)
+	if r.Ibsta&RQS == 0 {
+		t.Errorf("ibsta %v, want RQS", r.Ibsta)
+	}
+	if spr, _, err := Ibrsp(ud); err != nil || spr != 0x51 {
+		t.Errorf("Ibrsp = %#x, %v; want 0x51", spr, err)
+	}
+	// The serial poll clears the request.
+	if spr, _, _ := Ibrsp(ud); spr != 0x11 {
+		t.Errorf("second Ibrsp = %#x, want 0x11", spr)
+	}
+	if srq, _, _ := TestSRQ(0); srq != 0 {
+		t.Error("SRQ still asserted")
+	}
+	if r, _ := Ibwait(ud, 0); r.Ibsta&RQS != 0 {
+		t.Errorf("ibsta %v, want no RQS", r.Ibsta)
+	}
+}
+
+func TestSimConfigPrevious(t *testing.T) {
+	s := useSim(t)
+	_, ud := simDev(t, s, T100ms, 0)
+	tests := []struct {
+		ud        int
+		option    ConfigOption
+		v, before int
+	}{
+		{ud, IbcTMO, int(T1s), int(T100ms)},
+		{ud, IbcTMO, int(T3s), int(T1s)},
+		{ud, IbcEOT, 0, 1},
+		{ud, IbcEOT, 1, 0},
+		{ud, IbcEOSchar, '\r', 0},
+		{0, IbcAUTOPOLL, 1, 0},
+		{0, IbcAUTOPOLL, 0, 1},
+	}
+	for _, tt := range tests {
+		r, err := Ibconfig(tt.ud, tt.option, tt.v)
+		if err != nil || int(r.Iberr) != tt.before {
+			t.Errorf("Ibconfig(%d, %v, %d) iberr = %d, %v; want the old value %d",
+				tt.ud, tt.option, tt.v, r.Iberr, err, tt.before)
+		}
+		if v, _, _ := Ibask(tt.ud, tt.option); v != tt.v {
+			t.Errorf("Ibask(%d, %v) = %d, want %d", tt.ud, tt.option, v, tt.v)
+		}
+	}
+}
+
+func TestSimReceiveConcurrent(t *testing.T) {
+	s := useSim(t)
+	const n = 200
+	insts := map[Address]*SimInstrument{5: NewSimInstrument(nil), 22: NewSimInstrument(nil)}
+	for addr, inst := range insts {
+		s.Attach(0, addr.Primary(), NO_SAD, inst)
+		for i := 0; i < n; i++ {
+			inst.Queue([]byte(addr.String()))
+		}
+	}
+	// Each Receive addresses its device and reads from it before another
+	// can address the bus.
+	errs := make(chan error, 2)
+	for addr := range insts {
+		go func(addr Address) {
+			buf := make([]byte, 8)
+			for i := 0; i < n; i++ {
+				r := s.Receive(0, addr, buf, STOPend)
+				if got := string(buf[:r.Ibcntl]); r.Ibsta&ERR != 0 || got != addr.String() {
+					errs <- fmt.Errorf("Receive(%v) = %q, %+v", addr, got, r)
+					return
+				}
+			}
+			errs <- nil
+		}(addr)
+	}
+	for range insts {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestSimIblockx(t *testing.T) {
+	s := useSim(t)
+	_, ud := simDev(t, s, T100ms, 0)
+	s.HoldLock(0, true)
+	if r := s.Iblockx(ud, 10, ""); r.Iberr != ELCK {
+		t.Errorf("Iblockx of a held lock = %+v, want ELCK", r)
+	}
+	time.AfterFunc(30*time.Millisecond, func() { s.HoldLock(0, false) })
+	if r := s.Iblockx(ud, 1000, ""); r.Ibsta&ERR != 0 {
+		t.Errorf("Iblockx waiting for the lock = %+v", r)
+	}
+}
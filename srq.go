@@ -0,0 +1,207 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// stuckPoll is how often SRQDispatcher.Run serial polls the devices while
+// SRQ is asserted with none of them requesting service.
+const stuckPoll = 10 * time.Millisecond
+
+// ServiceRequest is a request for service found by an SRQDispatcher.
+type ServiceRequest struct {
+	Addr   Address
+	Status byte // the serial poll status byte, with bit 6 (0x40) set
+}
+
+// SRQHandler handles a request for service, e.g. by reading the result
+// the device announced. ctx is the one passed to SRQDispatcher.Run.
+type SRQHandler func(ctx context.Context, req ServiceRequest)
+
+// SRQDispatcher waits for SRQ on a board, serial polls the devices with a
+// handler to find those requesting service and calls their handlers, so
+// that instrument code only handles the requests of its own device.
+type SRQDispatcher struct {
+	boardID int
+
+	mu       sync.Mutex
+	addrs    []Address // in the order of Handle, which is the polling order
+	handlers map[Address]SRQHandler
+	onError  func(error)
+}
+
+// NewSRQDispatcher returns a dispatcher of the board boardID with no
+// handlers.
+func NewSRQDispatcher(boardID int) *SRQDispatcher {
+	return &SRQDispatcher{boardID: boardID, handlers: make(map[Address]SRQHandler)}
+}
+
+// Handle sets the handler of the requests of the device at addr, a nil h
+// removes it. Devices are serial polled in the order their handlers were
+// first set. Handlers are called one at a time from Run.
+func (s *SRQDispatcher) Handle(addr Address, h SRQHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.handlers[addr]
+	switch {
+	case h == nil && ok:
+		delete(s.handlers, addr)
+		for i, a := range s.addrs {
+			if a == addr {
+				s.addrs = append(s.addrs[:i], s.addrs[i+1:]...)
+				break
+			}
+		}
+	case h != nil:
+		if !ok {
+			s.addrs = append(s.addrs, addr)
+		}
+		s.handlers[addr] = h
+	}
+}
+
+// Chan returns a channel on which the requests of the device at addr are
+// sent, replacing its handler. Run waits for each request to be received,
+// or for its ctx to be done, so the channel must be drained while Run
+// runs. The channel is never closed.
+func (s *SRQDispatcher) Chan(addr Address) <-chan ServiceRequest {
+	ch := make(chan ServiceRequest, 1)
+	s.Handle(addr, func(ctx context.Context, req ServiceRequest) {
+		select {
+		case ch <- req:
+		case <-ctx.Done():
+		}
+	})
+	return ch
+}
+
+// HandleError sets the function Run reports the errors it continues after
+// to, which are otherwise dropped: a device that fails to answer its
+// serial poll, and SRQ asserted with no device with a handler requesting
+// service, which matches ErrSRQStuck and is reported once until SRQ is
+// released.
+func (s *SRQDispatcher) HandleError(f func(err error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onError = f
+}
+
+// Run dispatches service requests until ctx is done, when it returns
+// ctx.Err(), or until waiting for SRQ with Ibnotify fails.
+//
+// Autopolling is turned off on the board while Run runs and restored
+// afterwards: the driver would otherwise serial poll the devices first
+// and queue their status bytes on device descriptors, losing them (ESTB)
+// once the queues are full, and leave SRQ stuck (ESRQ) for a device it
+// has no descriptor of.
+func (s *SRQDispatcher) Run(ctx context.Context) error {
+	r, err := Ibconfig(s.boardID, IbcAUTOPOLL, 0)
+	switch {
+	case err == nil && r.Iberr != 0: // Ibconfig leaves the old value in iberr
+		defer Ibconfig(s.boardID, IbcAUTOPOLL, int(r.Iberr))
+	case err != nil && !errors.Is(err, ErrCapability):
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events, err := Notify(ctx, s.boardID, SRQI)
+	if err != nil {
+		return err
+	}
+	stuck := false
+	for ev := range events {
+		if _, err := check("ibnotify", ev.Ud, ev.Result); err != nil {
+			if errors.Is(err, ErrSRQStuck) {
+				s.fail(err)
+				continue
+			}
+			return err
+		}
+		if ev.Ibsta&SRQI == 0 || s.service(ctx) {
+			continue
+		}
+		// The event may be older than the serial polls that released
+		// SRQ.
+		srq, _, err := TestSRQ(s.boardID)
+		if err != nil {
+			return err
+		}
+		if srq == 0 {
+			stuck = false
+			continue
+		}
+		if !stuck {
+			s.fail(&Error{Op: "ibnotify", Ud: s.boardID, Sta: ev.Ibsta | ERR, Code: ESRQ})
+			stuck = true
+		}
+		// Poll again after a while, not as fast as the driver notifies
+		// of the stuck SRQ.
+		select {
+		case <-ctx.Done():
+		case <-time.After(stuckPoll):
+		}
+		// No event follows SRQ being released, so check for it here, or
+		// the next stuck SRQ would go unreported.
+		if srq, _, err := TestSRQ(s.boardID); err == nil && srq == 0 {
+			stuck = false
+		}
+	}
+	return ctx.Err()
+}
+
+// service serial polls the devices with a handler and dispatches their
+// requests. It reports whether any device requested service.
+func (s *SRQDispatcher) service(ctx context.Context) bool {
+	s.mu.Lock()
+	addrs := append([]Address(nil), s.addrs...)
+	s.mu.Unlock()
+	found := false
+	for len(addrs) > 0 {
+		spr, r, err := AllSpoll(s.boardID, addrs)
+		n := len(addrs)
+		if err != nil {
+			n = r.count(n)
+		}
+		for i := 0; i < n; i++ {
+			if spr[i]&0x40 != 0 {
+				found = true
+				s.dispatch(ctx, ServiceRequest{addrs[i], byte(spr[i])})
+			}
+		}
+		if err == nil {
+			break
+		}
+		// Report the device that failed and poll the ones after it.
+		s.fail(err)
+		if n >= len(addrs) {
+			break
+		}
+		addrs = addrs[n+1:]
+	}
+	return found
+}
+
+func (s *SRQDispatcher) dispatch(ctx context.Context, req ServiceRequest) {
+	s.mu.Lock()
+	h := s.handlers[req.Addr]
+	s.mu.Unlock()
+	if h != nil {
+		h(ctx, req)
+	}
+}
+
+func (s *SRQDispatcher) fail(err error) {
+	s.mu.Lock()
+	f := s.onError
+	s.mu.Unlock()
+	if f != nil {
+		f(err)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// runDispatcher runs d until the end of the test, when it checks that Run
+// returned context.Canceled.
+func runDispatcher(t *testing.T, d *SRQDispatcher) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != context.Canceled {
+			t.Errorf("Run = %v, want context.Canceled", err)
+		}
+	})
+}
+
+func receive(t *testing.T, ch <-chan ServiceRequest, want ServiceRequest) {
+	t.Helper()
+	select {
+	case req := <-ch:
+		if req != want {
+			t.Errorf("request %+v, want %+v", req, want)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("no request, want %+v", want)
+	}
+}
+
+func TestSRQDispatcher(t *testing.T) {
+	s := useSim(t)
+	insts := make([]*SimInstrument, 6)
+	for pad := 3; pad <= 5; pad++ {
+		insts[pad] = NewSimInstrument(nil)
+		s.Attach(0, pad, NO_SAD, insts[pad])
+	}
+	d := NewBoard(0).SRQDispatcher()
+	got := make(chan ServiceRequest, 4)
+	autopoll := make(chan int, 4)
+	d.Handle(3, func(ctx context.Context, req ServiceRequest) {
+		v, _, _ := Ibask(0, IbaAUTOPOLL)
+		autopoll <- v
+		got <- req
+	})
+	ch4 := d.Chan(4)
+	errs := make(chan error, 4)
+	d.HandleError(func(err error) { errs <- err })
+	if _, err := Ibconfig(0, IbcAUTOPOLL, 1); err != nil {
+		t.Fatal(err)
+	}
+	runDispatcher(t, d)
+
+	insts[3].RequestService(0x01)
+	receive(t, got, ServiceRequest{3, 0x41})
+	if v := <-autopoll; v != 0 {
+		t.Error("autopolling on while Run runs")
+	}
+	insts[4].RequestService(0x10)
+	receive(t, ch4, ServiceRequest{4, 0x50})
+
+	// Both are served from one SRQ, in the order of Handle.
+	insts[4].RequestService(0x02)
+	insts[3].RequestService(0x02)
+	receive(t, got, ServiceRequest{3, 0x42})
+	receive(t, ch4, ServiceRequest{4, 0x42})
+
+	// A device with no handler leaves SRQ stuck, which is reported once.
+	insts[5].RequestService(0)
+	select {
+	case err := <-errs:
+		if !errors.Is(err, ErrSRQStuck) {
+			t.Errorf("error %v, want ErrSRQStuck", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("stuck SRQ not reported")
+	}
+	// Requests of the devices with handlers are still served.
+	insts[3].RequestService(0x03)
+	receive(t, got, ServiceRequest{3, 0x43})
+	insts[5].SerialPoll()
+	select {
+	case err := <-errs:
+		t.Errorf("error %v reported again", err)
+	case <-time.After(5 * stuckPoll):
+	}
+
+	// With its handler removed, the device is no longer polled.
+	d.Handle(3, nil)
+	insts[3].RequestService(0x04)
+	select {
+	case err := <-errs:
+		if !errors.Is(err, ErrSRQStuck) {
+			t.Errorf("error %v, want ErrSRQStuck", err)
+		}
+	case req := <-got:
+		t.Errorf("request %+v after the handler was removed", req)
+	case <-time.After(time.Second):
+		t.Error("stuck SRQ not reported")
+	}
+	insts[3].SerialPoll()
+}
+
+func TestSRQDispatcherAutopoll(t *testing.T) {
+	for _, on := range []int{0, 1} {
+		useSim(t)
+		if _, err := Ibconfig(0, IbcAUTOPOLL, on); err != nil {
+			t.Fatal(err)
+		}
+		d := NewSRQDispatcher(0)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- d.Run(ctx) }()
+		time.Sleep(20 * time.Millisecond)
+		if v, _, _ := Ibask(0, IbaAUTOPOLL); v != 0 {
+			t.Errorf("autopolling %d while Run runs", v)
+		}
+		cancel()
+		if err := <-done; err != context.Canceled {
+			t.Errorf("Run = %v, want context.Canceled", err)
+		}
+		if v, _, _ := Ibask(0, IbaAUTOPOLL); v != on {
+			t.Errorf("autopolling %d after Run, want it restored to %d", v, on)
+		}
+	}
+}
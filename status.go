@@ -0,0 +1,86 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Status is an ibsta value, the GPIB status bit vector returned by every
+// NI-488 function and also used as the Ibwait and Ibnotify event mask.
+type Status uint32
+
+// String returns the names of the bits set in s, most significant first,
+// e.g. "ERR|TIMO|CMPL". Bits without a name are shown in hex.
+func (s Status) String() string {
+	if s == 0 {
+		return "0"
+	}
+	var names []string
+	for _, b := range statusBits {
+		if s&b.bit != 0 {
+			names = append(names, b.name)
+			s &^= b.bit
+		}
+	}
+	if s != 0 {
+		names = append(names, fmt.Sprintf("%#x", uint32(s)))
+	}
+	return strings.Join(names, "|")
+}
+
+// Has reports whether all the bits in mask are set in s.
+func (s Status) Has(mask Status) bool {
+	return s&mask == mask
+}
+
+// Err reports whether the call failed, iberr holds the reason.
+func (s Status) Err() bool { return s&ERR != 0 }
+
+// Timeout reports whether the call or Ibwait timed out.
+func (s Status) Timeout() bool { return s&TIMO != 0 }
+
+// End reports whether a read stopped on EOI or the EOS character.
+func (s Status) End() bool { return s&END != 0 }
+
+// SRQ reports whether a device is asserting SRQ while the board is CIC.
+func (s Status) SRQ() bool { return s&SRQI != 0 }
+
+// RQS reports whether the device is requesting service.
+func (s Status) RQS() bool { return s&RQS != 0 }
+
+// Complete reports whether the I/O operation has completed.
+func (s Status) Complete() bool { return s&CMPL != 0 }
+
+// Lockout reports whether the board is in the local lockout state.
+func (s Status) Lockout() bool { return s&LOK != 0 }
+
+// Remote reports whether the board is in the remote state.
+func (s Status) Remote() bool { return s&REM != 0 }
+
+// CIC reports whether the board is the Controller-In-Charge.
+func (s Status) CIC() bool { return s&CIC != 0 }
+
+// Result is the outcome of a single NI-488 or NI-488.2 call: the ibsta,
+// iberr and ibcntl values it left behind, captured together with the call
+// so that calls made from other goroutines cannot overwrite them.
+type Result struct {
+	Ibsta  Status
+	Iberr  ErrorCode // meaningful only when Ibsta has ERR set
+	Ibcntl int
+}
+
+// count returns ibcntl as the number of bytes or entries stored in a
+// buffer of n.
+func (r Result) count(n int) int {
+	if r.Ibcntl < 0 {
+		return 0
+	}
+	if r.Ibcntl < n {
+		return r.Ibcntl
+	}
+	return n
+}
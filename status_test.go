@@ -0,0 +1,41 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestStrings(t *testing.T) {
+	tests := []struct {
+		v    fmt.Stringer
+		want string
+	}{
+		{Status(0), "0"},
+		{CMPL, "CMPL"},
+		{ERR | TIMO | CMPL, "ERR|TIMO|CMPL"},
+		{END | RQS | CIC | DCAS, "END|RQS|CIC|DCAS"},
+		{ERR | 0x200, "ERR|0x200"},
+		{Status(0x30000), "0x30000"},
+		{EDVR, "EDVR"},
+		{ELCK, "ELCK"},
+		{WCFG, "WCFG"},
+		{ErrorCode(99), "iberr(99)"},
+		{IbcPAD, "IbcPAD"},
+		{IbcAUTOPOLL, "IbcAUTOPOLL"},
+		{IbaBNA, "IbaBNA"},
+		{ConfigOption(0x999), "ConfigOption(2457)"},
+		{T10s, "T10s"},
+		{Timeout(42), "Timeout(42)"},
+		{UNL, "UNL"},
+		{Command(0x7F), "Command(127)"},
+	}
+	for _, tt := range tests {
+		if got := tt.v.String(); got != tt.want {
+			t.Errorf("%T String() = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"context"
+	"io"
+)
+
+// defaultChunkSize is the chunk size of ReadTo and WriteFrom when
+// StreamOptions does not set one.
+const defaultChunkSize = 64 << 10
+
+// StreamOptions configures ReadTo and WriteFrom. A nil *StreamOptions
+// uses the defaults.
+type StreamOptions struct {
+	// ChunkSize is the number of bytes moved by each Ibrd or Ibwrt,
+	// 64 KiB if zero.
+	ChunkSize int
+
+	// Progress, when set, is called after every chunk with the number of
+	// bytes transferred so far.
+	Progress func(n int64)
+}
+
+func (o *StreamOptions) chunkSize() int {
+	if o == nil || o.ChunkSize <= 0 {
+		return defaultChunkSize
+	}
+	return o.ChunkSize
+}
+
+func (o *StreamOptions) progress(n int64) {
+	if o != nil && o.Progress != nil {
+		o.Progress(n)
+	}
+}
+
+// ReadTo reads a message from ud until END, in chunks, and writes it to w
+// as it arrives, so a transfer of any size goes to a file, a socket or a
+// compressor without being held in memory, unlike Ibrdf which only writes
+// to a file of the driver. It returns the number of bytes written to w.
+func ReadTo(ud int, w io.Writer, opts *StreamOptions) (n int64, err error) {
+	return ReadToContext(context.Background(), ud, w, opts)
+}
+
+// ReadToContext is ReadTo, aborted with Ibstop when ctx is done.
+func ReadToContext(ctx context.Context, ud int, w io.Writer, opts *StreamOptions) (n int64, err error) {
+	buf := make([]byte, opts.chunkSize())
+	for {
+		m, end, err := ReadContext(ctx, ud, buf)
+		if m > 0 {
+			k, werr := w.Write(buf[:m])
+			n += int64(k)
+			opts.progress(n)
+			if werr == nil && k < m {
+				werr = io.ErrShortWrite
+			}
+			if werr != nil {
+				return n, werr
+			}
+		}
+		switch {
+		case err != nil || end:
+			return n, err
+		case m == 0:
+			return n, io.ErrNoProgress
+		}
+	}
+}
+
+// WriteFrom writes everything read from r to ud, in chunks, as one
+// message: EOI is sent with the last byte of the final chunk only, and
+// the EOT setting of ud is restored afterwards. Unlike Ibwrtf, which only
+// reads a file of the driver, r can be anything. It returns the number of
+// bytes written to ud.
+func WriteFrom(ud int, r io.Reader, opts *StreamOptions) (n int64, err error) {
+	return WriteFromContext(context.Background(), ud, r, opts)
+}
+
+// WriteFromContext is WriteFrom, aborted with Ibstop when ctx is done.
+func WriteFromContext(ctx context.Context, ud int, r io.Reader, opts *StreamOptions) (n int64, err error) {
+	size := opts.chunkSize()
+	cur, next := make([]byte, size), make([]byte, size)
+	m, rerr := fill(r, cur)
+	if m == 0 {
+		if rerr == io.EOF {
+			rerr = nil
+		}
+		return 0, rerr
+	}
+	prev, set := -1, -1 // the EOT setting of ud before and now, -1 if unchanged
+	defer func() {
+		if prev >= 0 && prev != set {
+			Ibconfig(ud, IbcEOT, prev)
+		}
+	}()
+	setEOT := func(v int) error {
+		if v == set {
+			return nil
+		}
+		res, err := Ibconfig(ud, IbcEOT, v)
+		if err != nil {
+			return err
+		}
+		if prev < 0 {
+			prev = int(res.Iberr) // Ibconfig leaves the old value in iberr
+		}
+		set = v
+		return nil
+	}
+	for {
+		if rerr != nil && rerr != io.EOF {
+			return n, rerr
+		}
+		// The chunk is the final one if r is exhausted, which needs the
+		// next chunk read before it is written.
+		k, nerr := 0, rerr
+		if rerr == nil {
+			k, nerr = fill(r, next)
+		}
+		final := k == 0 && nerr == io.EOF
+		if err := setEOT(btoi(final)); err != nil {
+			return n, err
+		}
+		res, err := callContext(ctx, "ibwrt", ud, stopIO, func() Result {
+			return driver().Ibwrt(ud, cur[:m])
+		})
+		w := res.count(m)
+		n += int64(w)
+		opts.progress(n)
+		if err == nil && w < m {
+			err = io.ErrShortWrite
+		}
+		if err != nil || final {
+			return n, err
+		}
+		cur, next = next, cur
+		m, rerr = k, nerr
+	}
+}
+
+// fill reads into p until it is full or r is exhausted, which it reports
+// with io.EOF.
+func fill(r io.Reader, p []byte) (int, error) {
+	n, err := io.ReadFull(r, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
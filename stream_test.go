@@ -0,0 +1,153 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// chunkDev is a SimDevice recording the chunks written to it, and whether
+// each ended with EOI, and sending reply when addressed to talk.
+type chunkDev struct {
+	chunks []int
+	eoi    []bool
+	data   bytes.Buffer
+	reply  []byte
+}
+
+func (c *chunkDev) Listen(data []byte, end bool) {
+	c.chunks = append(c.chunks, len(data))
+	c.eoi = append(c.eoi, end)
+	c.data.Write(data)
+}
+
+func (c *chunkDev) Talk(ctx context.Context) ([]byte, error) {
+	if c.reply == nil {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	reply := c.reply
+	c.reply = nil
+	return reply, nil
+}
+
+func (c *chunkDev) Clear()           {}
+func (c *chunkDev) Trigger()         {}
+func (c *chunkDev) StatusByte() byte { return 0 }
+func (c *chunkDev) SerialPoll() byte { return 0 }
+
+func TestWriteFrom(t *testing.T) {
+	src := strings.Repeat("abcdefghij", 25)
+	tests := []struct {
+		name   string
+		eot    int
+		size   int // of the data written
+		chunks []int
+	}{
+		{"chunks", 1, 250, []int{100, 100, 50}},
+		{"multiple of the chunk size", 1, 200, []int{100, 100}},
+		{"one chunk", 1, 3, []int{3}},
+		{"EOT off", 0, 250, []int{100, 100, 50}},
+		{"empty", 1, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := useSim(t)
+			dev := &chunkDev{}
+			s.Attach(0, 7, NO_SAD, dev)
+			ud, _, err := Ibdev(0, 7, NO_SAD, T1s, tt.eot, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var progress []int64
+			opts := &StreamOptions{ChunkSize: 100, Progress: func(n int64) { progress = append(progress, n) }}
+			n, err := WriteFrom(ud, strings.NewReader(src[:tt.size]), opts)
+			if err != nil || n != int64(tt.size) || dev.data.String() != src[:tt.size] {
+				t.Fatalf("WriteFrom = %d, %v; device got %q", n, err, dev.data.String())
+			}
+			if !reflect.DeepEqual(dev.chunks, tt.chunks) {
+				t.Errorf("chunks %v, want %v", dev.chunks, tt.chunks)
+			}
+			// EOI goes with the last byte of the final chunk only.
+			for i, eoi := range dev.eoi {
+				if eoi != (i == len(dev.eoi)-1) {
+					t.Errorf("EOI %v on chunk %d of %d", eoi, i, len(dev.eoi))
+				}
+			}
+			if len(progress) != len(tt.chunks) || len(progress) > 0 && progress[len(progress)-1] != n {
+				t.Errorf("progress %v", progress)
+			}
+			if v, _, _ := Ibask(ud, IbaEOT); v != tt.eot {
+				t.Errorf("EOT %d after WriteFrom, want it restored to %d", v, tt.eot)
+			}
+		})
+	}
+}
+
+// errReader returns its data and then err.
+type errReader struct {
+	data []byte
+	err  error
+}
+
+func (r *errReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, r.err
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestWriteFromError(t *testing.T) {
+	s := useSim(t)
+	dev := &chunkDev{}
+	s.Attach(0, 7, NO_SAD, dev)
+	ud, _, err := Ibdev(0, 7, NO_SAD, T1s, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errRead := errors.New("read failed")
+	r := &errReader{data: []byte(strings.Repeat("x", 150)), err: errRead}
+	n, err := WriteFrom(ud, r, &StreamOptions{ChunkSize: 100})
+	if err != errRead || n != 100 {
+		t.Errorf("WriteFrom = %d, %v; want 100, the read error", n, err)
+	}
+	// The message is left unterminated.
+	if !reflect.DeepEqual(dev.eoi, []bool{false}) {
+		t.Errorf("EOI %v, want none", dev.eoi)
+	}
+	if v, _, _ := Ibask(ud, IbaEOT); v != 1 {
+		t.Errorf("EOT %d after WriteFrom, want it restored to 1", v)
+	}
+}
+
+func TestReadTo(t *testing.T) {
+	s := useSim(t)
+	dev := &chunkDev{reply: []byte(strings.Repeat("Z", 1000))}
+	s.Attach(0, 7, NO_SAD, dev)
+	ud, _, err := Ibdev(0, 7, NO_SAD, T100ms, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	var progress []int64
+	opts := &StreamOptions{ChunkSize: 300, Progress: func(n int64) { progress = append(progress, n) }}
+	n, err := ReadTo(ud, &out, opts)
+	if err != nil || n != 1000 || out.String() != strings.Repeat("Z", 1000) {
+		t.Errorf("ReadTo = %d, %v; got %d bytes", n, err, out.Len())
+	}
+	if want := []int64{300, 600, 900, 1000}; !reflect.DeepEqual(progress, want) {
+		t.Errorf("progress %v, want %v", progress, want)
+	}
+	if n, err := ReadTo(ud, &out, nil); n != 0 || !errors.Is(err, ErrTimeout) {
+		t.Errorf("ReadTo with nothing to read = %d, %v; want a timeout", n, err)
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Timeout is an I/O timeout code, TNONE or T10us to T1000s, as taken by
+// Ibtmo and Ibdev.
+type Timeout int
+
+// timeoutDurations maps the timeout codes onto durations, TNONE is zero.
+var timeoutDurations = [...]time.Duration{
+	0,
+	10 * time.Microsecond,
+	30 * time.Microsecond,
+	100 * time.Microsecond,
+	300 * time.Microsecond,
+	time.Millisecond,
+	3 * time.Millisecond,
+	10 * time.Millisecond,
+	30 * time.Millisecond,
+	100 * time.Millisecond,
+	300 * time.Millisecond,
+	time.Second,
+	3 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	100 * time.Second,
+	300 * time.Second,
+	1000 * time.Second,
+}
+
+// TimeoutFor returns the smallest timeout code of at least d. It returns
+// TNONE, no timeout, for a d of zero or less or above 1000s.
+func TimeoutFor(d time.Duration) Timeout {
+	if d <= 0 {
+		return TNONE
+	}
+	for t := T10us; t <= T1000s; t++ {
+		if timeoutDurations[t] >= d {
+			return Timeout(t)
+		}
+	}
+	return TNONE
+}
+
+// Duration returns the timeout as a duration, 0 for TNONE.
+func (t Timeout) Duration() time.Duration {
+	if t <= TNONE || int(t) >= len(timeoutDurations) {
+		return 0
+	}
+	return timeoutDurations[t]
+}
+
+// setTimeout sets the timeout of ud to the smallest code of at least d and
+// returns the effective timeout, 0 for none.
+func setTimeout(ud int, d time.Duration) (time.Duration, error) {
+	t := TimeoutFor(d)
+	if _, err := Ibtmo(ud, t); err != nil {
+		return 0, err
+	}
+	return t.Duration(), nil
+}
+
+// getTimeout returns the timeout of ud, 0 for none.
+func getTimeout(ud int) (time.Duration, error) {
+	v, _, err := Ibask(ud, IbaTMO)
+	if err != nil {
+		return 0, err
+	}
+	return Timeout(v).Duration(), nil
+}
+
+// overrides holds the timeouts set by deadlineTimeout on each descriptor
+// while calls lowering them are in progress.
+var overrides = struct {
+	sync.Mutex
+	m map[int]*tmoOverride
+}{m: make(map[int]*tmoOverride)}
+
+type tmoOverride struct {
+	orig Timeout // the timeout of ud before the first call
+	set  Timeout // the timeout set now
+	n    int     // the calls in progress
+}
+
+// deadlineTimeout lowers the timeout of ud to fit the deadline of ctx, if
+// it has one, and returns a func to call when the call is done. Overlapping
+// calls on ud each set the timeout they need, and the timeout ud had before
+// the first of them is restored when the last is done, whatever the order
+// they finish in.
+func deadlineTimeout(ctx context.Context, ud int) (done func()) {
+	done = func() {}
+	dl, ok := ctx.Deadline()
+	if !ok {
+		return
+	}
+	overrides.Lock()
+	defer overrides.Unlock()
+	o := overrides.m[ud]
+	if o == nil {
+		prev, r := driver().Ibask(ud, IbaTMO)
+		if r.Ibsta&ERR != 0 {
+			return
+		}
+		o = &tmoOverride{orig: Timeout(prev), set: Timeout(prev)}
+	}
+	t := TimeoutFor(time.Until(dl))
+	if t == TNONE || (o.orig != TNONE && o.orig < t) {
+		t = o.orig
+	}
+	if t != o.set {
+		if r := driver().Ibconfig(ud, IbcTMO, int(t)); r.Ibsta&ERR != 0 {
+			return
+		}
+		o.set = t
+	}
+	o.n++
+	overrides.m[ud] = o
+	return func() {
+		overrides.Lock()
+		defer overrides.Unlock()
+		if o.n--; o.n > 0 {
+			return
+		}
+		delete(overrides.m, ud)
+		if o.set != o.orig {
+			driver().Ibconfig(ud, IbcTMO, int(o.orig))
+		}
+	}
+}
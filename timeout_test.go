@@ -0,0 +1,83 @@
+// Copyright (c) 2011 Joseph D Poirier
+// Distributable under the terms of The New BSD License
+// that can be found in the LICENSE file.
+
+package ni488
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTimeoutFor(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want Timeout
+	}{
+		{-time.Second, TNONE},
+		{0, TNONE},
+		{1, T10us},
+		{10 * time.Microsecond, T10us},
+		{11 * time.Microsecond, T30us},
+		{250 * time.Millisecond, T300ms},
+		{2 * time.Second, T3s},
+		{1000 * time.Second, T1000s},
+		{1001 * time.Second, TNONE},
+	}
+	for _, tt := range tests {
+		if got := TimeoutFor(tt.d); got != tt.want {
+			t.Errorf("TimeoutFor(%v) = %v, want %v", tt.d, got, tt.want)
+		}
+	}
+	for tmo := T10us; tmo <= T1000s; tmo++ {
+		if got := TimeoutFor(Timeout(tmo).Duration()); got != Timeout(tmo) {
+			t.Errorf("TimeoutFor(%v.Duration()) = %v", Timeout(tmo), got)
+		}
+	}
+}
+
+func TestDeadlineTimeout(t *testing.T) {
+	s := useSim(t)
+	_, ud := simDev(t, s, T10s, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, _, err := ReadContext(ctx, ud, make([]byte, 4))
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, ErrAborted) {
+		t.Errorf("err %v, want EABO and the deadline", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("read returned after %v", d)
+	}
+	if v, _, _ := Ibask(ud, IbaTMO); Timeout(v) != T10s {
+		t.Errorf("timeout %v after the call, want T10s", Timeout(v))
+	}
+}
+
+// TestDeadlineTimeoutOverlap checks that the timeout of a descriptor is
+// restored when calls with deadlines on it overlap and the one started
+// first returns first.
+func TestDeadlineTimeoutOverlap(t *testing.T) {
+	s := useSim(t)
+	_, ud := simDev(t, s, T10s, 0)
+	read := func(delay, timeout time.Duration, wg *sync.WaitGroup) {
+		defer wg.Done()
+		time.Sleep(delay)
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if _, _, err := ReadContext(ctx, ud, make([]byte, 4)); !errors.Is(err, ErrAborted) {
+			t.Errorf("read with a %v deadline: %v", timeout, err)
+		}
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go read(0, 100*time.Millisecond, &wg)
+	go read(80*time.Millisecond, 30*time.Millisecond, &wg)
+	wg.Wait()
+	if v, _, _ := Ibask(ud, IbaTMO); Timeout(v) != T10s {
+		t.Errorf("timeout %v after the calls, want T10s", Timeout(v))
+	}
+}
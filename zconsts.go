@@ -0,0 +1,349 @@
+// Code generated by mkconsts.go from ni4882.h and ni488.h; DO NOT EDIT.
+
+package ni488
+
+import "strconv"
+
+// Command bytes, the GPIB interface messages sent with Ibcmd and SendCmds.
+const (
+	UNL Command = 0x3f // GPIB unlisten command
+	UNT Command = 0x5f // GPIB untalk command
+	GTL Command = 0x01 // GPIB go to local
+	SDC Command = 0x04 // GPIB selected device clear
+	PPC Command = 0x05 // GPIB parallel poll configure
+	GET Command = 0x08 // GPIB group execute trigger
+	TCT Command = 0x09 // GPIB take control
+	LLO Command = 0x11 // GPIB local lock out
+	DCL Command = 0x14 // GPIB device clear
+	PPU Command = 0x15 // GPIB parallel poll unconfigure
+	SPE Command = 0x18 // GPIB serial poll enable
+	SPD Command = 0x19 // GPIB serial poll disable
+	PPE Command = 0x60 // GPIB parallel poll enable
+	PPD Command = 0x70 // GPIB parallel poll disable
+)
+
+var commandNames = map[Command]string{
+	UNL: "UNL",
+	UNT: "UNT",
+	GTL: "GTL",
+	SDC: "SDC",
+	PPC: "PPC",
+	GET: "GET",
+	TCT: "TCT",
+	LLO: "LLO",
+	DCL: "DCL",
+	PPU: "PPU",
+	SPE: "SPE",
+	SPD: "SPD",
+	PPE: "PPE",
+	PPD: "PPD",
+}
+
+// String returns the name of the constant with the value of v.
+func (v Command) String() string {
+	if s, ok := commandNames[v]; ok {
+		return s
+	}
+	return "Command(" + strconv.Itoa(int(v)) + ")"
+}
+
+// Status bits of ibsta, also the Ibwait and Ibnotify event mask.
+const (
+	ERR  Status = 1 << 15 // Error detected
+	TIMO Status = 1 << 14 // Timeout
+	END  Status = 1 << 13 // EOI or EOS detected
+	SRQI Status = 1 << 12 // SRQ detected by CIC
+	RQS  Status = 1 << 11 // Device needs service
+	CMPL Status = 1 << 8  // I/O completed
+	LOK  Status = 1 << 7  // Local lockout state
+	REM  Status = 1 << 6  // Remote state
+	CIC  Status = 1 << 5  // Controller-in-Charge
+	ATN  Status = 1 << 4  // Attention asserted
+	TACS Status = 1 << 3  // Talker active
+	LACS Status = 1 << 2  // Listener active
+	DTAS Status = 1 << 1  // Device trigger state
+	DCAS Status = 1 << 0  // Device clear state
+)
+
+// statusBits names the status bits, most significant first.
+var statusBits = []struct {
+	bit  Status
+	name string
+}{
+	{ERR, "ERR"},
+	{TIMO, "TIMO"},
+	{END, "END"},
+	{SRQI, "SRQI"},
+	{RQS, "RQS"},
+	{CMPL, "CMPL"},
+	{LOK, "LOK"},
+	{REM, "REM"},
+	{CIC, "CIC"},
+	{ATN, "ATN"},
+	{TACS, "TACS"},
+	{LACS, "LACS"},
+	{DTAS, "DTAS"},
+	{DCAS, "DCAS"},
+}
+
+// Error codes of iberr, meaningful only when ERR is set in ibsta.
+const (
+	EDVR ErrorCode = 0  // System error
+	ECIC ErrorCode = 1  // Function requires GPIB board to be CIC
+	ENOL ErrorCode = 2  // Write function detected no Listeners
+	EADR ErrorCode = 3  // Interface board not addressed correctly
+	EARG ErrorCode = 4  // Invalid argument to function call
+	ESAC ErrorCode = 5  // Function requires GPIB board to be SAC
+	EABO ErrorCode = 6  // I/O operation aborted
+	ENEB ErrorCode = 7  // Non-existent interface board
+	EDMA ErrorCode = 8  // Error performing DMA
+	EOIP ErrorCode = 10 // I/O operation started before previous operation completed
+	ECAP ErrorCode = 11 // No capability for intended operation
+	EFSO ErrorCode = 12 // File system operation error
+	EBUS ErrorCode = 14 // Command error during device call
+	ESTB ErrorCode = 15 // Serial poll status byte lost
+	ESRQ ErrorCode = 16 // SRQ remains asserted
+	ETAB ErrorCode = 20 // The return buffer is full
+	ELCK ErrorCode = 21 // Address or board is locked
+	EARM ErrorCode = 22 // The ibnotify Callback failed to rearm
+	EHDL ErrorCode = 23 // The input handle is invalid
+	EWIP ErrorCode = 26 // Wait already in progress on input ud
+	ERST ErrorCode = 27 // The event notification was cancelled due to a reset of the interface
+	EPWR ErrorCode = 28 // The system or board has lost power or gone to standby
+	WCFG ErrorCode = 24 // Configuration warning
+	ECFG ErrorCode = WCFG
+)
+
+var errorCodeNames = map[ErrorCode]string{
+	EDVR: "EDVR",
+	ECIC: "ECIC",
+	ENOL: "ENOL",
+	EADR: "EADR",
+	EARG: "EARG",
+	ESAC: "ESAC",
+	EABO: "EABO",
+	ENEB: "ENEB",
+	EDMA: "EDMA",
+	EOIP: "EOIP",
+	ECAP: "ECAP",
+	EFSO: "EFSO",
+	EBUS: "EBUS",
+	ESTB: "ESTB",
+	ESRQ: "ESRQ",
+	ETAB: "ETAB",
+	ELCK: "ELCK",
+	EARM: "EARM",
+	EHDL: "EHDL",
+	EWIP: "EWIP",
+	ERST: "ERST",
+	EPWR: "EPWR",
+	WCFG: "WCFG",
+}
+
+// String returns the name of the constant with the value of v.
+func (v ErrorCode) String() string {
+	if s, ok := errorCodeNames[v]; ok {
+		return s
+	}
+	return "iberr(" + strconv.Itoa(int(v)) + ")"
+}
+
+// Timeout codes of Ibtmo and Ibdev, the times are ideal ones.
+const (
+	TNONE  Timeout = 0  // Infinite timeout (disabled)
+	T10us  Timeout = 1  // Timeout of 10 us (ideal)
+	T30us  Timeout = 2  // Timeout of 30 us (ideal)
+	T100us Timeout = 3  // Timeout of 100 us (ideal)
+	T300us Timeout = 4  // Timeout of 300 us (ideal)
+	T1ms   Timeout = 5  // Timeout of 1 ms (ideal)
+	T3ms   Timeout = 6  // Timeout of 3 ms (ideal)
+	T10ms  Timeout = 7  // Timeout of 10 ms (ideal)
+	T30ms  Timeout = 8  // Timeout of 30 ms (ideal)
+	T100ms Timeout = 9  // Timeout of 100 ms (ideal)
+	T300ms Timeout = 10 // Timeout of 300 ms (ideal)
+	T1s    Timeout = 11 // Timeout of 1 s (ideal)
+	T3s    Timeout = 12 // Timeout of 3 s (ideal)
+	T10s   Timeout = 13 // Timeout of 10 s (ideal)
+	T30s   Timeout = 14 // Timeout of 30 s (ideal)
+	T100s  Timeout = 15 // Timeout of 100 s (ideal)
+	T300s  Timeout = 16 // Timeout of 300 s (ideal)
+	T1000s Timeout = 17 // Timeout of 1000 s (ideal)
+)
+
+var timeoutNames = map[Timeout]string{
+	TNONE:  "TNONE",
+	T10us:  "T10us",
+	T30us:  "T30us",
+	T100us: "T100us",
+	T300us: "T300us",
+	T1ms:   "T1ms",
+	T3ms:   "T3ms",
+	T10ms:  "T10ms",
+	T30ms:  "T30ms",
+	T100ms: "T100ms",
+	T300ms: "T300ms",
+	T1s:    "T1s",
+	T3s:    "T3s",
+	T10s:   "T10s",
+	T30s:   "T30s",
+	T100s:  "T100s",
+	T300s:  "T300s",
+	T1000s: "T1000s",
+}
+
+// String returns the name of the constant with the value of v.
+func (v Timeout) String() string {
+	if s, ok := timeoutNames[v]; ok {
+		return s
+	}
+	return "Timeout(" + strconv.Itoa(int(v)) + ")"
+}
+
+// Configuration options of Ibconfig (Ibc) and Ibask (Iba).
+const (
+	IbcPAD            ConfigOption = 0x0001 // Primary Address
+	IbcSAD            ConfigOption = 0x0002 // Secondary Address
+	IbcTMO            ConfigOption = 0x0003 // Timeout Value
+	IbcEOT            ConfigOption = 0x0004 // Send EOI with last data byte?
+	IbcPPC            ConfigOption = 0x0005 // Parallel Poll Configure
+	IbcREADDR         ConfigOption = 0x0006 // Repeat Addressing
+	IbcAUTOPOLL       ConfigOption = 0x0007 // Disable Auto Serial Polling
+	IbcCICPROT        ConfigOption = 0x0008 // Use the CIC Protocol?
+	IbcIRQ            ConfigOption = 0x0009 // Use PIO for I/O
+	IbcSC             ConfigOption = 0x000A // Board is System Controller?
+	IbcSRE            ConfigOption = 0x000B // Assert SRE on device calls?
+	IbcEOSrd          ConfigOption = 0x000C // Terminate reads on EOS
+	IbcEOSwrt         ConfigOption = 0x000D // Send EOI with EOS character
+	IbcEOScmp         ConfigOption = 0x000E // Use 7 or 8-bit EOS compare
+	IbcEOSchar        ConfigOption = 0x000F // The EOS character
+	IbcPP2            ConfigOption = 0x0010 // Use Parallel Poll Mode 2
+	IbcTIMING         ConfigOption = 0x0011 // NORMAL, HIGH, or VERY_HIGH timing
+	IbcDMA            ConfigOption = 0x0012 // Use DMA for I/O
+	IbcReadAdjust     ConfigOption = 0x0013 // Swap bytes during an ibrd
+	IbcWriteAdjust    ConfigOption = 0x014  // Swap bytes during an ibwrt
+	IbcSendLLO        ConfigOption = 0x0017 // Enable/disable the sending of LLO
+	IbcSPollTime      ConfigOption = 0x0018 // Set the timeout value for serial polls
+	IbcPPollTime      ConfigOption = 0x0019 // Set the parallel poll length period
+	IbcEndBitIsNormal ConfigOption = 0x001A // Remove EOS from END bit of IBSTA
+	IbcUnAddr         ConfigOption = 0x001B // Enable/disable device unaddressing
+	IbcSignalNumber   ConfigOption = 0x001C // Set UNIX signal number - unsupported
+	IbcBlockIfLocked  ConfigOption = 0x001D // Enable/disable blocking for locked boards/devices
+	IbcHSCableLength  ConfigOption = 0x001F // Length of cable specified for high speed timing
+	IbcIst            ConfigOption = 0x0020 // Set the IST bit
+	IbcRsv            ConfigOption = 0x0021 // Set the RSV byte
+	IbcLON            ConfigOption = 0x0022 // Enter listen only mode
+	IbcEOS            ConfigOption = 0x0025 // Macro for ibeos
+	IbaPAD            ConfigOption = IbcPAD
+	IbaSAD            ConfigOption = IbcSAD
+	IbaTMO            ConfigOption = IbcTMO
+	IbaEOT            ConfigOption = IbcEOT
+	IbaPPC            ConfigOption = IbcPPC
+	IbaREADDR         ConfigOption = IbcREADDR
+	IbaAUTOPOLL       ConfigOption = IbcAUTOPOLL
+	IbaCICPROT        ConfigOption = IbcCICPROT
+	IbaIRQ            ConfigOption = IbcIRQ
+	IbaSC             ConfigOption = IbcSC
+	IbaSRE            ConfigOption = IbcSRE
+	IbaEOSrd          ConfigOption = IbcEOSrd
+	IbaEOSwrt         ConfigOption = IbcEOSwrt
+	IbaEOScmp         ConfigOption = IbcEOScmp
+	IbaEOSchar        ConfigOption = IbcEOSchar
+	IbaPP2            ConfigOption = IbcPP2
+	IbaTIMING         ConfigOption = IbcTIMING
+	IbaDMA            ConfigOption = IbcDMA
+	IbaReadAdjust     ConfigOption = IbcReadAdjust
+	IbaWriteAdjust    ConfigOption = IbcWriteAdjust
+	IbaSendLLO        ConfigOption = IbcSendLLO
+	IbaSPollTime      ConfigOption = IbcSPollTime
+	IbaPPollTime      ConfigOption = IbcPPollTime
+	IbaEndBitIsNormal ConfigOption = IbcEndBitIsNormal
+	IbaUnAddr         ConfigOption = IbcUnAddr
+	IbaSignalNumber   ConfigOption = IbcSignalNumber
+	IbaBlockIfLocked  ConfigOption = IbcBlockIfLocked
+	IbaHSCableLength  ConfigOption = IbcHSCableLength
+	IbaIst            ConfigOption = IbcIst
+	IbaRsv            ConfigOption = IbcRsv
+	IbaLON            ConfigOption = IbcLON
+	IbaSerialNumber   ConfigOption = 0x0023
+	IbaEOS            ConfigOption = IbcEOS
+	IbaBNA            ConfigOption = 0x0200 // A device's access board
+)
+
+var configOptionNames = map[ConfigOption]string{
+	IbcPAD:            "IbcPAD",
+	IbcSAD:            "IbcSAD",
+	IbcTMO:            "IbcTMO",
+	IbcEOT:            "IbcEOT",
+	IbcPPC:            "IbcPPC",
+	IbcREADDR:         "IbcREADDR",
+	IbcAUTOPOLL:       "IbcAUTOPOLL",
+	IbcCICPROT:        "IbcCICPROT",
+	IbcIRQ:            "IbcIRQ",
+	IbcSC:             "IbcSC",
+	IbcSRE:            "IbcSRE",
+	IbcEOSrd:          "IbcEOSrd",
+	IbcEOSwrt:         "IbcEOSwrt",
+	IbcEOScmp:         "IbcEOScmp",
+	IbcEOSchar:        "IbcEOSchar",
+	IbcPP2:            "IbcPP2",
+	IbcTIMING:         "IbcTIMING",
+	IbcDMA:            "IbcDMA",
+	IbcReadAdjust:     "IbcReadAdjust",
+	IbcWriteAdjust:    "IbcWriteAdjust",
+	IbcSendLLO:        "IbcSendLLO",
+	IbcSPollTime:      "IbcSPollTime",
+	IbcPPollTime:      "IbcPPollTime",
+	IbcEndBitIsNormal: "IbcEndBitIsNormal",
+	IbcUnAddr:         "IbcUnAddr",
+	IbcSignalNumber:   "IbcSignalNumber",
+	IbcBlockIfLocked:  "IbcBlockIfLocked",
+	IbcHSCableLength:  "IbcHSCableLength",
+	IbcIst:            "IbcIst",
+	IbcRsv:            "IbcRsv",
+	IbcLON:            "IbcLON",
+	IbcEOS:            "IbcEOS",
+	IbaSerialNumber:   "IbaSerialNumber",
+	IbaBNA:            "IbaBNA",
+}
+
+// String returns the name of the constant with the value of v.
+func (v ConfigOption) String() string {
+	if s, ok := configOptionNames[v]; ok {
+		return s
+	}
+	return "ConfigOption(" + strconv.Itoa(int(v)) + ")"
+}
+
+// EOS mode bits, IBLN secondary addresses, Send and Receive modes,
+// iblines bits and the iblockx wait times.
+const (
+	BIN                      = 1 << 12 // Eight bit compare
+	XEOS                     = 1 << 11 // Send END with EOS byte
+	REOS                     = 1 << 10 // Terminate read on EOS
+	NO_SAD                   = 0
+	ALL_SAD                  = -1
+	NULLend                  = 0x00 // Do nothing at the end of a transfer
+	NLend                    = 0x01 // Send NL with EOI after a transfer
+	DABend                   = 0x02 // Send EOI with the last DAB
+	STOPend                  = 0x0100
+	NOADDR                   = -1
+	ValidEOI                 = 0x0080
+	ValidATN                 = 0x0040
+	ValidSRQ                 = 0x0020
+	ValidREN                 = 0x0010
+	ValidIFC                 = 0x0008
+	ValidNRFD                = 0x0004
+	ValidNDAC                = 0x0002
+	ValidDAV                 = 0x0001
+	BusEOI                   = -32768
+	BusATN                   = 0x4000
+	BusSRQ                   = 0x2000
+	BusREN                   = 0x1000
+	BusIFC                   = 0x0800
+	BusNRFD                  = 0x0400
+	BusNDAC                  = 0x0200
+	BusDAV                   = 0x0100
+	TIMMEDIATE               = -1
+	TINFINITE                = -2
+	MAX_LOCKSHARENAME_LENGTH = 64
+)